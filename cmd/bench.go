@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/brnocorreia/concurrency/internal/config/logger"
+	"github.com/brnocorreia/concurrency/internal/entity"
+	"github.com/brnocorreia/concurrency/internal/runner"
+	"github.com/brnocorreia/concurrency/internal/tools"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	benchSizes   []int
+	benchAttacks []int
+	benchPlayers []int
+	benchPowers  []int
+	benchRuns    int
+	benchMode    string
+	benchOutput  string
+	benchClock   string
+	benchSeed    int64
+	benchDist    string
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Benchmark the execution modes over a grid of parameters",
+	Long: `Bench runs every selected mode K times for each combination of matrix size,
+number of attacks, number of players and player power, and reports the mean,
+standard deviation, p50/p95/p99 duration and throughput of each mode.
+
+Durations are measured on the wall clock. With the virtual clock (the default)
+hits don't sleep, so the numbers measure the cost of the synchronization itself.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		clock, err := tools.NewClock(benchClock)
+		if err != nil {
+			logger.Info("Invalid clock:", zap.String("clock", benchClock))
+			os.Exit(1)
+		}
+		if benchRuns < 1 {
+			logger.Info("Invalid number of runs:", zap.Int("runs", benchRuns))
+			os.Exit(1)
+		}
+		for name, values := range map[string][]int{
+			"size":    benchSizes,
+			"attacks": benchAttacks,
+			"players": benchPlayers,
+			"power":   benchPowers,
+		} {
+			for _, value := range values {
+				if value < 1 {
+					logger.Info("Invalid value:", zap.String("flag", name), zap.Int("value", value))
+					os.Exit(1)
+				}
+			}
+		}
+
+		strategies, err := parseModes(benchMode)
+		if err != nil {
+			logger.Info("Invalid mode:", zap.String("mode", benchMode))
+			os.Exit(1)
+		}
+
+		if !cmd.Flags().Changed("seed") {
+			benchSeed = tools.NewSeed()
+		}
+
+		distribution, err := tools.ParseDistribution(benchDist)
+		if err != nil {
+			logger.Info("Invalid distribution:", zap.Error(err))
+			os.Exit(1)
+		}
+
+		// Os logs de cada ataque dominariam as medições, então só os erros são
+		// registrados, a não ser que LOG_LEVEL seja definido
+		if os.Getenv(logger.LOG_LEVEL) == "" {
+			logger.SetLevel(zapcore.ErrorLevel)
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			stop()
+		}()
+
+		results := runner.RunBench(ctx, runner.Bench{
+			Grid: runner.BenchGrid{
+				MatrixSizes:  benchSizes,
+				NumAttacks:   benchAttacks,
+				NumPlayers:   benchPlayers,
+				PlayerPowers: benchPowers,
+			},
+			Strategies:   strategies,
+			Runs:         benchRuns,
+			Seed:         benchSeed,
+			Clock:        clock,
+			Distribution: distribution,
+		})
+
+		printBenchTable(results)
+
+		if err := runner.SaveBenchResults(results, benchOutput); err != nil {
+			fmt.Println("Error saving benchmark results:", err)
+			os.Exit(1)
+		}
+		fmt.Println("Benchmark results saved in:", benchOutput)
+
+		if results.Interrupted {
+			fmt.Println("Benchmark interrupted, results are partial")
+			os.Exit(exitInterrupted)
+		}
+	},
+}
+
+func printBenchTable(results runner.BenchResults) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "MODE\tSIZE\tATTACKS\tPLAYERS\tPOWER\tRUNS\tMEAN\tSTDDEV\tP50\tP95\tP99\tATTACKS/S\t")
+	for _, stats := range results.Stats {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%v\t%v\t%v\t%v\t%v\t%.0f\t\n",
+			stats.Mode, stats.MatrixSize, stats.NumAttacks, stats.NumPlayers, stats.PlayerPower, stats.Runs,
+			roundDuration(stats.Mean), roundDuration(stats.StdDev),
+			roundDuration(stats.P50), roundDuration(stats.P95), roundDuration(stats.P99),
+			stats.Throughput)
+	}
+	w.Flush()
+}
+
+// Arredonda as durações para que a tabela fique legível
+func roundDuration(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(time.Microsecond)
+	default:
+		return d
+	}
+}
+
+func init() {
+	benchCmd.Flags().IntSliceVarP(&benchSizes, "size", "s", []int{8}, "Matrix sizes")
+	benchCmd.Flags().IntSliceVarP(&benchAttacks, "attacks", "a", []int{256}, "Numbers of attacks")
+	benchCmd.Flags().IntSliceVar(&benchPlayers, "players", []int{2}, "Numbers of players")
+	benchCmd.Flags().IntSliceVarP(&benchPowers, "power", "p", []int{30}, "Player powers")
+	benchCmd.Flags().IntVarP(&benchRuns, "runs", "k", 10, "Runs of each mode for every combination of parameters")
+	benchCmd.Flags().StringVarP(&benchMode, "mode", "m", "all", fmt.Sprintf("Execution modes, comma separated (%s), or all", strings.Join(entity.StrategyNames(), ", ")))
+	benchCmd.Flags().StringVarP(&benchOutput, "output", "o", "bench.json", "Benchmark results file")
+	benchCmd.Flags().StringVar(&benchClock, "clock", tools.ClockVirtual, "Clock used to time the hits (real or virtual)")
+	benchCmd.Flags().Int64Var(&benchSeed, "seed", 0, "Seed of the first run's attack sequences, run k uses seed+k (random if not set)")
+	benchCmd.Flags().StringVar(&benchDist, "distribution", tools.DistributionUniform, distributionUsage)
+
+	rootCmd.AddCommand(benchCmd)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+
+	"github.com/brnocorreia/concurrency/internal/config/logger"
+	"github.com/brnocorreia/concurrency/internal/tools"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate the attack sequences",
+	Run: func(cmd *cobra.Command, args []string) {
+		if !cmd.Flags().Changed("seed") {
+			seed = tools.NewSeed()
+		}
+
+		shape, err := boardShape()
+		if err != nil {
+			logger.Info("Invalid board:", zap.Error(err))
+			os.Exit(1)
+		}
+
+		distribution, err := tools.ParseDistribution(distributionName)
+		if err != nil {
+			logger.Info("Invalid distribution:", zap.Error(err))
+			os.Exit(1)
+		}
+
+		if _, err := tools.Generate(shape, numAttacks, numPlayers, seed, distribution); err != nil {
+			logger.Info("Error generating sequences:", zap.Error(err))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	generateCmd.Flags().IntVarP(&numAttacks, "attacks", "a", 256, "Number of attacks")
+	generateCmd.Flags().IntVarP(&matrixSize, "size", "s", 8, "Matrix size")
+	addShapeFlags(generateCmd)
+	generateCmd.Flags().IntVar(&numPlayers, "players", 2, "Number of players")
+	generateCmd.Flags().Int64Var(&seed, "seed", 0, "Seed used to generate the sequences (random if not set)")
+	generateCmd.Flags().StringVar(&distributionName, "distribution", tools.DistributionUniform, distributionUsage)
+
+	rootCmd.AddCommand(generateCmd)
+}
@@ -1,16 +1,45 @@
 package main
 
 import (
+	"bytes"
+	"cmp"
+	"context"
 	"fmt"
+	"maps"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/brnocorreia/concurrency/internal/config"
 	"github.com/brnocorreia/concurrency/internal/config/logger"
+	"github.com/brnocorreia/concurrency/internal/entity"
+	"github.com/brnocorreia/concurrency/internal/metrics"
+	"github.com/brnocorreia/concurrency/internal/replay"
 	"github.com/brnocorreia/concurrency/internal/runner"
 	"github.com/brnocorreia/concurrency/internal/tools"
+	"github.com/brnocorreia/concurrency/internal/trace"
+	"github.com/brnocorreia/concurrency/internal/tui"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
+// Código de saída quando o jogo é interrompido por um sinal, seguindo a convenção 128 + SIGINT
+const exitInterrupted = 130
+
+// Código de saída quando o watchdog aborta alguma execução
+const exitStalled = 3
+
+// Código de saída quando as réplicas de alguma execução divergem
+const exitDiverged = 4
+
+// Código de saída quando o histórico de alguma execução não é linearizável
+const exitNotLinearizable = 5
+
 // Semaphore -> https://medium.com/@deckarep/gos-extended-concurrency-semaphores-part-1-5eeabfa351ce
 
 var (
@@ -19,8 +48,30 @@ var (
 	playerPower int
 	mode        string
 	regenerate  bool
+	output      string
+	clockName   string
+	seed        int64
+	numPlayers  int
+	showTUI     bool
+	eventsFile  string
+	recordFile  string
+	metricsAddr string
+	configFile  string
+	blockHealth int
+	evenHitTime time.Duration
+	oddHitTime  time.Duration
+	layoutFile  string
+	blockMix    string
+
+	distributionName string
+	watchdogWindow   time.Duration
+	checkInterval    time.Duration
+	linearizable     bool
+	chaosSpec        string
 )
 
+var distributionUsage = fmt.Sprintf("Distribution of the attack targets (%s), with an optional parameter like zipf:1.5", strings.Join(tools.DistributionNames(), ", "))
+
 var rootCmd = &cobra.Command{
 	Use:   "concurrency",
 	Short: "Concurrency is a game developed for study purposes under UFBA's MATA58 course",
@@ -31,60 +82,392 @@ var runCmd = &cobra.Command{
 	Use:   "run",
 	Short: "Run the game",
 	Run: func(cmd *cobra.Command, args []string) {
-		runner := runner.NewRunner(numAttacks, matrixSize, playerPower)
+		settings, err := applyConfig(cmd)
+		if err != nil {
+			logger.Info("Invalid configuration:", zap.Error(err))
+			os.Exit(1)
+		}
 
-		if regenerate {
-			logger.Info("Regenerating attack sequences...")
-			_, err := tools.Generate(matrixSize, numAttacks)
-			if err != nil {
-				logger.Info("Error generating sequences:", zap.Error(err))
-				os.Exit(1)
-			}
+		// A lista de players do arquivo só vale se nem --players nem --power
+		// foram informados, por flag ou variável de ambiente
+		var powers []int
+		var sequenceFiles []string
+		if settings != nil && len(settings.Players) > 0 && !cmd.Flags().Changed("players") && !cmd.Flags().Changed("power") {
+			powers = settings.PlayerPowers(playerPower)
+			numPlayers = len(powers)
+			sequenceFiles = settings.SequenceFiles(tools.SequenceFilename)
+		}
+
+		clock, err := tools.NewClock(clockName)
+		if err != nil {
+			logger.Info("Invalid clock:", zap.String("clock", clockName))
+			os.Exit(1)
+		}
+		if numPlayers < 1 {
+			logger.Info("Invalid number of players:", zap.Int("players", numPlayers))
+			os.Exit(1)
+		}
+		if blockHealth < 1 || evenHitTime <= 0 || oddHitTime <= 0 {
+			logger.Info("Invalid block rules:", zap.Int("health", blockHealth), zap.Duration("even", evenHitTime), zap.Duration("odd", oddHitTime))
+			os.Exit(1)
+		}
+		shape, err := boardShape()
+		if err != nil {
+			logger.Info("Invalid board:", zap.Error(err))
+			os.Exit(1)
+		}
+		distribution, err := tools.ParseDistribution(distributionName)
+		if err != nil {
+			logger.Info("Invalid distribution:", zap.Error(err))
+			os.Exit(1)
+		}
+		strategies, err := parseModes(mode)
+		if err != nil {
+			logger.Info("Invalid mode:", zap.String("mode", mode))
+			os.Exit(1)
+		}
+
+		game := runner.NewRunner(numAttacks, matrixSize, playerPower, numPlayers, clock)
+		game.SetShape(shape)
+		if powers != nil {
+			game.SetPowers(powers)
+		}
+		if sequenceFiles != nil {
+			game.SetSequenceFiles(sequenceFiles)
 		}
 
-		if !tools.FileExists("sequence_1.json") || !tools.FileExists("sequence_2.json") {
+		// Sem --seed, as sequências novas usam uma semente aleatória que fica registrada nos arquivos
+		seedChanged := cmd.Flags().Changed("seed")
+		if !seedChanged {
+			seed = tools.NewSeed()
+		}
+
+		layout, err := blockLayout(settings, shape)
+		if err != nil {
+			logger.Info("Invalid block layout:", zap.Error(err))
+			os.Exit(1)
+		}
+		game.SetBlockRules(entity.BlockRules{Health: blockHealth, EvenHitTime: evenHitTime, OddHitTime: oddHitTime, Layout: layout})
+
+		// Com o relógio real, um único ataque não pode durar a janela inteira
+		if longest := game.BlockRules().LongestHit(); watchdogWindow < 0 || (watchdogWindow > 0 && clockName == tools.ClockReal && watchdogWindow <= longest) {
+			logger.Info("Invalid watchdog window, it must be longer than the longest hit:", zap.Duration("watchdog", watchdogWindow), zap.Duration("longest_hit", longest))
+			os.Exit(1)
+		}
+		game.SetWatchdog(watchdogWindow)
+		if checkInterval < 0 {
+			logger.Info("Invalid replica check interval:", zap.Duration("check-replicas", checkInterval))
+			os.Exit(1)
+		}
+		game.SetConsistencyCheck(checkInterval)
+		game.SetLinearizability(linearizable)
+		chaos, err := tools.ParseChaos(chaosSpec)
+		if err != nil {
+			logger.Info("Invalid chaos faults:", zap.Error(err))
+			os.Exit(1)
+		}
+		game.SetChaos(chaos)
+
+		generate := true
+		switch {
+		case sequenceFiles != nil:
+			// As sequências indicadas no arquivo de configuração nunca são sobrescritas
+			generate = false
+		case regenerate:
+			logger.Info("Regenerating attack sequences...")
+		case !tools.SequencesExist(numPlayers):
 			logger.Info("Attack sequences not found, generating...")
-			_, err := tools.Generate(matrixSize, numAttacks)
-			if err != nil {
+		case seedChanged && !sequencesHaveSeed(seed):
+			logger.Info("Attack sequences were not generated with the given seed, generating...", zap.Int64("seed", seed))
+		case !sequencesHaveDistribution(distribution):
+			logger.Info("Attack sequences were not generated with the given distribution, generating...", zap.Stringer("distribution", distribution))
+		default:
+			generate = false
+		}
+		if generate {
+			if _, err := tools.Generate(shape, numAttacks, numPlayers, seed, distribution); err != nil {
 				logger.Info("Error generating sequences:", zap.Error(err))
 				os.Exit(1)
 			}
 		}
 
-		_, err := runner.LoadSequence()
+		_, err = game.LoadSequence()
 		if err != nil {
 			logger.Info("Error loading sequences:", zap.Error(err))
 			os.Exit(1)
 		}
 
-		switch mode {
-		case "mutex":
-			runner.RunMutex()
-		case "semaphore":
-			runner.RunSemaphore()
-		case "messages":
-			runner.RunMessages()
-		case "all":
-			runner.RunMutex()
-			runner.RunSemaphore()
-			runner.RunMessages()
-		default:
-			logger.Info("Invalid mode:", zap.String("mode", mode))
+		// Ctrl-C ou SIGTERM interrompem o jogo, que ainda salva os resultados parciais.
+		// Um segundo sinal encerra o processo imediatamente
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			stop()
+		}()
+
+		// Os logs de cada ataque embaralhariam a tela, então só os erros são
+		// registrados durante o jogo, a não ser que LOG_LEVEL seja definido
+		quiet := showTUI && os.Getenv(logger.LOG_LEVEL) == ""
+		if quiet {
+			logger.SetLevel(zapcore.ErrorLevel)
+		}
+
+		var events *trace.Writer
+		if eventsFile != "" {
+			file, err := os.Create(eventsFile)
+			if err != nil {
+				logger.Info("Error creating events file:", zap.Error(err))
+				os.Exit(1)
+			}
+			defer file.Close()
+			events = trace.NewWriter(file)
+		}
+
+		var recorder *replay.Recorder
+		if recordFile != "" {
+			recorder = replay.NewRecorder()
+		}
+
+		var registry *metrics.Registry
+		if metricsAddr != "" {
+			// O Listen é feito antes do jogo para que um endereço ocupado seja avisado logo
+			listener, err := net.Listen("tcp", metricsAddr)
+			if err != nil {
+				logger.Info("Error serving metrics:", zap.Error(err))
+				os.Exit(1)
+			}
+			registry = metrics.NewRegistry()
+			mux := http.NewServeMux()
+			mux.Handle("GET /metrics", registry)
+			srv := &http.Server{Handler: mux}
+			go srv.Serve(listener)
+			defer srv.Close()
+			logger.Info("Serving metrics on:", zap.String("url", fmt.Sprintf("http://%s/metrics", listener.Addr())))
+		}
+
+		var runs []runner.RunResult
+		stalled, diverged, violated := 0, 0, 0
+		for _, strategy := range strategies {
+			if ctx.Err() != nil {
+				break
+			}
+			run := runStrategy(ctx, game, strategy, events, recorder, registry)
+			if run.Stalled != nil {
+				stalled++
+			}
+			if len(run.Divergences) > 0 {
+				diverged++
+			}
+			if run.Linearizability != nil && len(run.Linearizability.Violations) > 0 {
+				violated++
+			}
+			runs = append(runs, run)
+		}
+
+		if quiet {
+			logger.SetLevel(zapcore.InfoLevel)
+		}
+
+		if events != nil {
+			if err := events.Flush(); err != nil {
+				logger.Info("Error writing events:", zap.Error(err))
+				os.Exit(1)
+			}
+			logger.Info("Events saved in:", zap.String("filename", eventsFile))
+		}
+
+		if recorder != nil {
+			if err := recorder.Save(game.Results(runs).Config, recordFile); err != nil {
+				logger.Info("Error saving recording:", zap.Error(err))
+				os.Exit(1)
+			}
+			logger.Info("Recording saved in:", zap.String("filename", recordFile))
+		}
+
+		if err := runner.SaveResults(game.Results(runs), output); err != nil {
+			logger.Info("Error saving results:", zap.Error(err))
 			os.Exit(1)
 		}
+		logger.Info("Results saved in:", zap.String("filename", output))
+
+		if ctx.Err() != nil {
+			logger.Info("Game interrupted, results are partial")
+			os.Exit(exitInterrupted)
+		}
+		if stalled > 0 {
+			logger.Info("The watchdog aborted stalled runs, see the wait-for graph above:", zap.Int("runs", stalled))
+			os.Exit(exitStalled)
+		}
+		if diverged > 0 {
+			logger.Info("Replicas disagree, see the divergent blocks above:", zap.Int("runs", diverged))
+			os.Exit(exitDiverged)
+		}
+		if violated > 0 {
+			logger.Info("Hit histories are not linearizable, see the minimal sub-histories above:", zap.Int("runs", violated))
+			os.Exit(exitNotLinearizable)
+		}
 	},
 }
 
 func init() {
 	runCmd.Flags().IntVarP(&numAttacks, "attacks", "a", 256, "Number of attacks")
 	runCmd.Flags().IntVarP(&matrixSize, "size", "s", 8, "Matrix size")
+	addShapeFlags(runCmd)
+	runCmd.Flags().StringVar(&distributionName, "distribution", tools.DistributionUniform, distributionUsage)
 	runCmd.Flags().IntVarP(&playerPower, "power", "p", 30, "Player power")
-	runCmd.Flags().StringVarP(&mode, "mode", "m", "all", "Execution mode (mutex, semaphore, or messages)")
+	runCmd.Flags().IntVar(&numPlayers, "players", 2, "Number of players")
+	runCmd.Flags().StringVarP(&mode, "mode", "m", "all", fmt.Sprintf("Execution modes, comma separated (%s), or all", strings.Join(entity.StrategyNames(), ", ")))
 	runCmd.Flags().BoolVarP(&regenerate, "regenerate", "r", false, "Regenerate attack sequences")
+	runCmd.Flags().StringVarP(&output, "output", "o", "results.json", "Results file")
+	runCmd.Flags().StringVar(&clockName, "clock", tools.ClockReal, "Clock used to time the hits (real or virtual)")
+	runCmd.Flags().Int64Var(&seed, "seed", 0, "Seed used when generating attack sequences (random if not set)")
+	runCmd.Flags().BoolVar(&showTUI, "tui", false, "Show the board being attacked live in the terminal")
+	runCmd.Flags().StringVar(&eventsFile, "events", "", "Write every attempt, lock, hit, kill and update to this file as NDJSON")
+	runCmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "Serve Prometheus metrics of the game at http://<addr>/metrics while it runs")
+	runCmd.Flags().DurationVar(&watchdogWindow, "watchdog", 0, "Abort a mode when no block event happens for this long, printing the wait-for graph and goroutine stacks (off by default, it tracks every event)")
+	runCmd.Flags().DurationVar(&checkInterval, "check-replicas", 0, "Also compare the replicas of the messages mode while the game runs, at this interval (0 compares them only at the end)")
+	runCmd.Flags().BoolVar(&linearizable, "linearizability", false, "Record every hit and check that the history of each block is linearizable, printing the minimal sub-history of each violation")
+	runCmd.Flags().StringVar(&chaosSpec, "chaos", "", "Inject faults in the locks, the update messages and the hits, like delay:1ms,reorder:0.2,panic:0.01 (seed:N fixes the draws), and report which invariants survived")
+	runCmd.Flags().StringVar(&recordFile, "record", "", "Record the order in which hits were applied to each block, for the replay command")
+	runCmd.Flags().StringVar(&configFile, "config", "", "Read the game settings from a JSON, YAML or TOML file; flags and CONCURRENCY_* env vars override it")
+	runCmd.Flags().IntVar(&blockHealth, "block-health", entity.DefaultBlockRules.Health, "Initial health of every block")
+	runCmd.Flags().DurationVar(&evenHitTime, "hit-time-even", entity.DefaultBlockRules.EvenHitTime, "How long a hit takes on blocks with an even id")
+	runCmd.Flags().DurationVar(&oddHitTime, "hit-time-odd", entity.DefaultBlockRules.OddHitTime, "How long a hit takes on blocks with an odd id")
+	runCmd.Flags().StringVar(&layoutFile, "block-layout", "", "File with the type of each block, one matrix row per line")
+	runCmd.Flags().StringVar(&blockMix, "block-mix", "", fmt.Sprintf("Draw the type of each block from weights, like fast:1,armored:1,standard:2 (%s)", strings.Join(entity.BlockTypeNames(entity.BlockTypes), ", ")))
 
 	rootCmd.AddCommand(runCmd)
 }
 
+// Executa uma estratégia ligando os eventos dos blocos ao arquivo de eventos,
+// à gravação, às métricas e à tela, quando pedidos
+func runStrategy(ctx context.Context, game *runner.Runner, strategy entity.Strategy, events *trace.Writer, recorder *replay.Recorder, registry *metrics.Registry) (result runner.RunResult) {
+	var sinks entity.Sinks
+	if events != nil {
+		events.SetMode(strategy.Name)
+		sinks = append(sinks, events)
+	}
+	if recorder != nil {
+		recorder.Start(strategy.Name)
+		sinks = append(sinks, recorder)
+		defer func() { recorder.Finish(result) }()
+	}
+	if registry != nil {
+		sinks = append(sinks, registry.Sink(strategy.Name))
+	}
+
+	if !showTUI {
+		if len(sinks) > 0 {
+			game.SetEvents(sinks)
+		}
+		return game.Run(ctx, strategy)
+	}
+
+	// O estado final só é impresso depois que a tela para de ser redesenhada
+	var final bytes.Buffer
+	screen := tui.NewScreen(os.Stdout, strategy.Label, game.Shape().Width, game.Shape().Height, numPlayers, game.BlockRules())
+	game.SetEvents(append(sinks, screen))
+	game.SetOutput(&final)
+	screen.Start()
+	result = game.Run(ctx, strategy)
+	screen.Stop()
+	os.Stdout.Write(final.Bytes())
+	return result
+}
+
+// Lê o arquivo de --config, ou de CONCURRENCY_CONFIG, e aplica os seus valores
+// e os das variáveis de ambiente nos flags que não foram passados
+func applyConfig(cmd *cobra.Command) (*config.File, error) {
+	if value, ok := os.LookupEnv(config.EnvName("config")); ok {
+		configFile = value
+	}
+	var file *config.File
+	if configFile != "" {
+		var err error
+		file, err = config.Load(configFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return file, config.Apply(cmd.Flags(), file, os.LookupEnv)
+}
+
+// Tipo de cada bloco, lido de --block-layout ou sorteado com a regra de
+// --block-mix e a semente do jogo. Nil quando todos são do tipo standard
+func blockLayout(settings *config.File, shape tools.Shape) ([]entity.BlockType, error) {
+	types := maps.Clone(entity.BlockTypes)
+	if settings != nil {
+		maps.Copy(types, settings.BlockTypes())
+	}
+
+	switch {
+	case layoutFile != "" && blockMix != "":
+		return nil, fmt.Errorf("use --block-layout ou --block-mix, não os dois")
+	case layoutFile != "":
+		file, err := os.Open(layoutFile)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		layout, err := entity.ParseBlockLayout(file, shape.Width, shape.Height, types)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", layoutFile, err)
+		}
+		return layout, nil
+	case blockMix != "":
+		mix, err := entity.ParseBlockMix(blockMix, types)
+		if err != nil {
+			return nil, err
+		}
+		return entity.GenerateBlockLayout(shape.Width, shape.Height, mix, seed), nil
+	}
+	return nil, nil
+}
+
+// Estratégias de uma lista separada por vírgulas, na ordem informada, ou
+// todas para "all"
+func parseModes(modes string) ([]entity.Strategy, error) {
+	if modes == "all" {
+		return entity.Strategies(), nil
+	}
+	var strategies []entity.Strategy
+	for _, name := range strings.Split(modes, ",") {
+		strategy, ok := entity.LookupStrategy(strings.TrimSpace(name))
+		if !ok {
+			return nil, fmt.Errorf("modo desconhecido %q", name)
+		}
+		strategies = append(strategies, strategy)
+	}
+	return strategies, nil
+}
+
+// Indica se as sequências existentes foram geradas com a semente informada
+func sequencesHaveSeed(seed int64) bool {
+	for i := 1; i <= numPlayers; i++ {
+		sequence, err := tools.LoadSequenceFile(tools.SequenceFilename(i))
+		if err != nil || sequence.Version == 0 || sequence.Seed != seed {
+			return false
+		}
+	}
+	return true
+}
+
+// Indica se as sequências existentes foram geradas com a distribuição
+// informada. Arquivos que não registram a distribuição são uniformes
+func sequencesHaveDistribution(distribution tools.Distribution) bool {
+	for i := 1; i <= numPlayers; i++ {
+		sequence, err := tools.LoadSequenceFile(tools.SequenceFilename(i))
+		if err != nil {
+			return false
+		}
+		if got := cmp.Or(sequence.Distribution, tools.DistributionUniform); got != distribution.String() {
+			return false
+		}
+	}
+	return true
+}
+
 func main() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
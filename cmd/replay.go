@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/brnocorreia/concurrency/internal/entity"
+	"github.com/brnocorreia/concurrency/internal/replay"
+	"github.com/spf13/cobra"
+)
+
+var (
+	replayMode  string
+	replayStep  bool
+	replaySpeed float64
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay <recording>",
+	Short: "Re-apply a recorded game to a fresh board and verify its final state",
+	Long: `Re-apply the hits saved by "run --record", in the order they were applied to each block, to a fresh board and verify that the final health, kills and points match the recorded results.
+
+With --step the game advances one hit at a time, waiting for Enter (q and Enter runs the rest without stopping). With --speed the hits are shown following the recorded timing, scaled by the given factor.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if replaySpeed < 0 {
+			fmt.Printf("invalid speed: %v\n", replaySpeed)
+			os.Exit(1)
+		}
+		recording, err := replay.Load(args[0])
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		runs := recording.Runs
+		if replayMode != "all" {
+			runs = nil
+			for _, run := range recording.Runs {
+				if run.Mode == replayMode {
+					runs = append(runs, run)
+				}
+			}
+			if len(runs) == 0 {
+				fmt.Printf("mode %s not found in %s\n", replayMode, args[0])
+				os.Exit(1)
+			}
+		}
+
+		input := bufio.NewScanner(os.Stdin)
+		mismatches := 0
+		for _, run := range runs {
+			stepping := replayStep
+			var last time.Duration
+			visit := func(step replay.Step, before int, state *replay.State) {
+				if !stepping && replaySpeed == 0 {
+					return
+				}
+				if replaySpeed > 0 {
+					time.Sleep(time.Duration(float64(step.T-last) / replaySpeed))
+					last = step.T
+				}
+				printStep(run.Mode, step, before, state)
+				if stepping {
+					printReplica(state, max(step.Replica-1, 0))
+					fmt.Print("[Enter: next, q: run to the end] ")
+					if !input.Scan() || strings.TrimSpace(input.Text()) == "q" {
+						stepping = false
+					}
+				}
+			}
+
+			// Um jogo abortado pelo watchdog não tem o estado final dos blocos
+			if run.Result.Stalled != nil {
+				fmt.Printf("%s: the recorded run was aborted by the watchdog, there is no final state to compare\n", run.Mode)
+				continue
+			}
+
+			state, diffs := replay.Replay(recording, run, visit)
+			if stepping || replaySpeed > 0 {
+				fmt.Println()
+			}
+			for r := range state.Health {
+				if len(state.Health) > 1 {
+					fmt.Printf("%s replica %d:\n", run.Mode, r+1)
+				} else {
+					fmt.Printf("%s:\n", run.Mode)
+				}
+				printReplica(state, r)
+			}
+			if run.Result.Interrupted {
+				fmt.Printf("%s: the recorded run was interrupted, its results are partial\n", run.Mode)
+			}
+			if len(diffs) == 0 {
+				fmt.Printf("%s: %d step(s) replayed, final state matches the recording\n", run.Mode, len(run.Steps))
+				continue
+			}
+			for _, diff := range diffs {
+				fmt.Printf("%s: %s\n", run.Mode, diff)
+			}
+			mismatches++
+		}
+
+		if mismatches > 0 {
+			fmt.Printf("%d of %d run(s) do not match the recording\n", mismatches, len(runs))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	replayCmd.Flags().StringVarP(&replayMode, "mode", "m", "all", fmt.Sprintf("Recorded mode to replay (%s, or all)", strings.Join(entity.StrategyNames(), ", ")))
+	replayCmd.Flags().BoolVar(&replayStep, "step", false, "Step through the game one hit at a time, waiting for Enter")
+	replayCmd.Flags().Float64Var(&replaySpeed, "speed", 0, "Show the hits following the recorded timing scaled by this factor (0 replays instantly)")
+
+	rootCmd.AddCommand(replayCmd)
+}
+
+// Imprime um passo da reprodução
+func printStep(mode string, step replay.Step, before int, state *replay.State) {
+	where := fmt.Sprintf("block %d", step.Block)
+	if step.Replica > 0 {
+		where = fmt.Sprintf("replica %d block %d", step.Replica, step.Block)
+	}
+
+	switch step.Kind {
+	case entity.EventHit:
+		fmt.Printf("%s %10v  player %d hits %s: %d -> %d", mode, step.T, step.Player, where, before, step.Health)
+		if step.Health == 0 {
+			fmt.Print(" (destroyed)")
+		}
+		fmt.Println()
+	case entity.EventUpdatePropagated:
+		fmt.Printf("%s %10v  update from player %d reaches %s: %d -> %d\n", mode, step.T, step.Player, where, before, step.Health)
+	}
+}
+
+// Imprime a saúde de cada bloco de uma réplica do estado, com os buracos
+// do tabuleiro em branco
+func printReplica(state *replay.State, replica int) {
+	for y := range state.Height {
+		for x := range state.Width {
+			block := y*state.Width + x
+			if state.Types[block].Name == entity.HoleBlock {
+				fmt.Print("    ")
+				continue
+			}
+			fmt.Printf("%3d ", state.Health[replica][block])
+		}
+		fmt.Println()
+	}
+}
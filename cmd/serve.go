@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/brnocorreia/concurrency/internal/config/logger"
+	"github.com/brnocorreia/concurrency/internal/server"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var serveAddr string
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve a web page to start games and watch them live",
+	Long: `Serve starts an HTTP server with a page where games can be started and watched
+live. Games are started with POST /runs and their hit, lock and kill events are
+streamed as server-sent events from GET /runs/{id}/events. Counters and histograms
+of every game are exposed for Prometheus at GET /metrics.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		// Os logs de cada ataque de todas as execuções encheriam o terminal,
+		// então só os erros são registrados, a não ser que LOG_LEVEL seja definido
+		if os.Getenv(logger.LOG_LEVEL) == "" {
+			logger.SetLevel(zapcore.ErrorLevel)
+		}
+
+		srv := &http.Server{
+			Addr:    serveAddr,
+			Handler: server.New(ctx),
+		}
+		go func() {
+			<-ctx.Done()
+			shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			srv.Shutdown(shutdown)
+		}()
+
+		fmt.Printf("Serving on http://%s\n", serveAddr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("Error serving:", err, zap.String("addr", serveAddr))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "localhost:8080", "Address to listen on")
+
+	rootCmd.AddCommand(serveCmd)
+}
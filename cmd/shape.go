@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/brnocorreia/concurrency/internal/tools"
+	"github.com/spf13/cobra"
+)
+
+var (
+	boardWidth  int
+	boardHeight int
+	maskFile    string
+)
+
+// Registra os flags que mudam o formato do tabuleiro definido por --size
+func addShapeFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&boardWidth, "width", 0, "Matrix width, overrides --size")
+	cmd.Flags().IntVar(&boardHeight, "height", 0, "Matrix height, overrides --size")
+	cmd.Flags().StringVar(&maskFile, "mask", "", "File with the board shape: one row per line, # for a block and . for a hole")
+}
+
+// Formato do tabuleiro pedido nos flags. A máscara define as dimensões, e
+// --width e --height, quando informados, precisam concordar com ela
+func boardShape() (tools.Shape, error) {
+	width, height := matrixSize, matrixSize
+	if boardWidth > 0 {
+		width = boardWidth
+	}
+	if boardHeight > 0 {
+		height = boardHeight
+	}
+
+	if maskFile == "" {
+		if width < 1 || height < 1 {
+			return tools.Shape{}, fmt.Errorf("matriz %dx%d inválida", width, height)
+		}
+		return tools.NewShape(width, height), nil
+	}
+
+	shape, err := tools.LoadMask(maskFile)
+	if err != nil {
+		return tools.Shape{}, err
+	}
+	if boardWidth > 0 && boardWidth != shape.Width {
+		return tools.Shape{}, fmt.Errorf("%s: a máscara tem largura %d, mas --width é %d", maskFile, shape.Width, boardWidth)
+	}
+	if boardHeight > 0 && boardHeight != shape.Height {
+		return tools.Shape{}, fmt.Errorf("%s: a máscara tem altura %d, mas --height é %d", maskFile, shape.Height, boardHeight)
+	}
+	return shape, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/brnocorreia/concurrency/internal/tools"
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [files...]",
+	Short: "Validate attack sequence files against the board shape",
+	Long:  `Validate attack sequence files against the board dimensions and holes, reporting every problem found. Without arguments, the sequence files of each player are validated.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		shape, err := boardShape()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		files := args
+		if len(files) == 0 {
+			for i := 1; i <= numPlayers; i++ {
+				files = append(files, tools.SequenceFilename(i))
+			}
+		}
+
+		problems := 0
+		for _, filename := range files {
+			sequence, err := tools.LoadSequenceFile(filename)
+			if err != nil {
+				fmt.Printf("%s: %v\n", filename, err)
+				problems++
+				continue
+			}
+
+			errs := tools.ValidateSequence(sequence, filename, shape)
+			for _, err := range errs {
+				fmt.Println(err)
+			}
+			problems += len(errs)
+		}
+
+		if problems > 0 {
+			fmt.Printf("%d problem(s) found in %d file(s)\n", problems, len(files))
+			os.Exit(1)
+		}
+		fmt.Printf("%d file(s) OK\n", len(files))
+	},
+}
+
+func init() {
+	validateCmd.Flags().IntVarP(&matrixSize, "size", "s", 8, "Matrix size")
+	addShapeFlags(validateCmd)
+	validateCmd.Flags().IntVar(&numPlayers, "players", 2, "Number of players whose sequence files are validated when no file is given")
+
+	rootCmd.AddCommand(validateCmd)
+}
@@ -0,0 +1,275 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/brnocorreia/concurrency/internal/entity"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// Prefixo das variáveis de ambiente que sobrescrevem os flags
+const EnvPrefix = "CONCURRENCY_"
+
+// Arquivo de configuração do comando run. Todos os campos são opcionais, e
+// os ausentes mantêm o valor dos flags
+type File struct {
+	Size    *int     `json:"size" yaml:"size" toml:"size"`
+	Width   *int     `json:"width" yaml:"width" toml:"width"`
+	Height  *int     `json:"height" yaml:"height" toml:"height"`
+	Mask    string   `json:"mask" yaml:"mask" toml:"mask"`
+	Attacks *int     `json:"attacks" yaml:"attacks" toml:"attacks"`
+	Modes   []string `json:"modes" yaml:"modes" toml:"modes"`
+	Seed    *int64   `json:"seed" yaml:"seed" toml:"seed"`
+	// Distribuição dos alvos, como "zipf:1.5"
+	Distribution string `json:"distribution" yaml:"distribution" toml:"distribution"`
+	Clock        string `json:"clock" yaml:"clock" toml:"clock"`
+	Regenerate   *bool  `json:"regenerate" yaml:"regenerate" toml:"regenerate"`
+	Output       string `json:"output" yaml:"output" toml:"output"`
+	Events       string `json:"events" yaml:"events" toml:"events"`
+	Record       string `json:"record" yaml:"record" toml:"record"`
+	MetricsAddr  string `json:"metrics_addr" yaml:"metrics_addr" toml:"metrics_addr"`
+	TUI          *bool  `json:"tui" yaml:"tui" toml:"tui"`
+	// Janela sem eventos depois da qual o watchdog aborta o jogo
+	Watchdog *Duration `json:"watchdog" yaml:"watchdog" toml:"watchdog"`
+	// Intervalo da comparação das réplicas durante o jogo
+	CheckReplicas *Duration `json:"check_replicas" yaml:"check_replicas" toml:"check_replicas"`
+	// Verifica se o histórico de ataques de cada bloco é linearizável
+	Linearizability *bool `json:"linearizability" yaml:"linearizability" toml:"linearizability"`
+	// Falhas do modo caos, como "delay:1ms,reorder:0.2"
+	Chaos  string `json:"chaos" yaml:"chaos" toml:"chaos"`
+	Blocks Blocks `json:"blocks" yaml:"blocks" toml:"blocks"`
+	// Poder dos players da lista que não informam o seu
+	Power   *int     `json:"power" yaml:"power" toml:"power"`
+	Players []Player `json:"players" yaml:"players" toml:"players"`
+}
+
+type Blocks struct {
+	Health  *int    `json:"health" yaml:"health" toml:"health"`
+	HitTime HitTime `json:"hit_time" yaml:"hit_time" toml:"hit_time"`
+	// Arquivo de layout ou regra de geração com o tipo de cada bloco
+	Layout string `json:"layout" yaml:"layout" toml:"layout"`
+	Mix    string `json:"mix" yaml:"mix" toml:"mix"`
+	// Tipos de bloco além dos padrões, que podem ser usados no layout e na regra
+	Types map[string]BlockType `json:"types" yaml:"types" toml:"types"`
+}
+
+// Tipo de bloco definido no arquivo. Campos ausentes seguem as regras dos blocos
+type BlockType struct {
+	Health  int       `json:"health" yaml:"health" toml:"health"`
+	HitTime *Duration `json:"hit_time" yaml:"hit_time" toml:"hit_time"`
+	Regen   int       `json:"regen" yaml:"regen" toml:"regen"`
+}
+
+// Duração de um ataque nos blocos de id par e de id ímpar
+type HitTime struct {
+	Even *Duration `json:"even" yaml:"even" toml:"even"`
+	Odd  *Duration `json:"odd" yaml:"odd" toml:"odd"`
+}
+
+// Player da lista, na ordem dos ids. Sem Sequence, o player usa o arquivo
+// de sequência padrão
+type Player struct {
+	Power    int    `json:"power" yaml:"power" toml:"power"`
+	Sequence string `json:"sequence" yaml:"sequence" toml:"sequence"`
+}
+
+// Duração escrita como texto, por exemplo "500ms"
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// Lê o arquivo de configuração no formato indicado pela extensão: .json,
+// .yaml, .yml ou .toml. Campos desconhecidos são erros
+func Load(filename string) (*File, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var file File
+	switch ext := strings.ToLower(filepath.Ext(filename)); ext {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&file)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&file)
+		// Um arquivo vazio não tem nenhum documento
+		if err != nil && len(bytes.TrimSpace(data)) == 0 {
+			err = nil
+		}
+	case ".toml":
+		var meta toml.MetaData
+		meta, err = toml.Decode(string(data), &file)
+		if err == nil && len(meta.Undecoded()) > 0 {
+			err = fmt.Errorf("campo desconhecido %q", meta.Undecoded()[0].String())
+		}
+	default:
+		return nil, fmt.Errorf("%s: formato desconhecido %q, use .json, .yaml, .yml ou .toml", filename, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return &file, nil
+}
+
+// Valores do arquivo indexados pelo nome do flag correspondente. A lista de
+// players não tem flag e fica de fora
+func (f *File) Values() map[string]string {
+	values := make(map[string]string)
+	setInt := func(name string, value *int) {
+		if value != nil {
+			values[name] = strconv.Itoa(*value)
+		}
+	}
+	setString := func(name, value string) {
+		if value != "" {
+			values[name] = value
+		}
+	}
+	setBool := func(name string, value *bool) {
+		if value != nil {
+			values[name] = strconv.FormatBool(*value)
+		}
+	}
+	setDuration := func(name string, value *Duration) {
+		if value != nil {
+			values[name] = value.String()
+		}
+	}
+
+	setInt("size", f.Size)
+	setInt("width", f.Width)
+	setInt("height", f.Height)
+	setString("mask", f.Mask)
+	setInt("attacks", f.Attacks)
+	if len(f.Modes) > 0 {
+		values["mode"] = strings.Join(f.Modes, ",")
+	}
+	if f.Seed != nil {
+		values["seed"] = strconv.FormatInt(*f.Seed, 10)
+	}
+	setString("clock", f.Clock)
+	setString("distribution", f.Distribution)
+	setBool("regenerate", f.Regenerate)
+	setString("output", f.Output)
+	setString("events", f.Events)
+	setString("record", f.Record)
+	setString("metrics-addr", f.MetricsAddr)
+	setBool("tui", f.TUI)
+	setDuration("watchdog", f.Watchdog)
+	setDuration("check-replicas", f.CheckReplicas)
+	setBool("linearizability", f.Linearizability)
+	setString("chaos", f.Chaos)
+	setInt("block-health", f.Blocks.Health)
+	setDuration("hit-time-even", f.Blocks.HitTime.Even)
+	setDuration("hit-time-odd", f.Blocks.HitTime.Odd)
+	setString("block-layout", f.Blocks.Layout)
+	setString("block-mix", f.Blocks.Mix)
+	// Sem a lista, o poder do arquivo vale para todos os players
+	if len(f.Players) == 0 {
+		setInt("power", f.Power)
+	}
+	return values
+}
+
+// Poder de cada player da lista, usando defaultPower para quem não informa
+func (f *File) PlayerPowers(defaultPower int) []int {
+	if f.Power != nil {
+		defaultPower = *f.Power
+	}
+	powers := make([]int, len(f.Players))
+	for i, player := range f.Players {
+		powers[i] = player.Power
+		if powers[i] == 0 {
+			powers[i] = defaultPower
+		}
+	}
+	return powers
+}
+
+// Arquivo de sequência de cada player da lista, ou nil se nenhum informa o seu
+func (f *File) SequenceFiles(defaultName func(id int) string) []string {
+	custom := false
+	files := make([]string, len(f.Players))
+	for i, player := range f.Players {
+		files[i] = player.Sequence
+		if files[i] == "" {
+			files[i] = defaultName(i + 1)
+		} else {
+			custom = true
+		}
+	}
+	if !custom {
+		return nil
+	}
+	return files
+}
+
+// Tipos de bloco definidos no arquivo, indexados pelo nome
+func (f *File) BlockTypes() map[string]entity.BlockType {
+	types := make(map[string]entity.BlockType, len(f.Blocks.Types))
+	for name, t := range f.Blocks.Types {
+		blockType := entity.BlockType{Name: name, Health: t.Health, Regen: t.Regen}
+		if t.HitTime != nil {
+			blockType.HitTime = time.Duration(*t.HitTime)
+		}
+		types[name] = blockType
+	}
+	return types
+}
+
+// Nome da variável de ambiente de um flag, como CONCURRENCY_HIT_TIME_EVEN
+func EnvName(flag string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// Aplica o arquivo e as variáveis de ambiente nos flags. Os flags passados na
+// linha de comando têm prioridade sobre o arquivo, e as variáveis de ambiente
+// sobre os dois. file pode ser nil
+func Apply(flags *pflag.FlagSet, file *File, lookupEnv func(string) (string, bool)) error {
+	if file != nil {
+		for name, value := range file.Values() {
+			if flags.Lookup(name) == nil || flags.Changed(name) {
+				continue
+			}
+			if err := flags.Set(name, value); err != nil {
+				return fmt.Errorf("arquivo de configuração: %s: %w", name, err)
+			}
+		}
+	}
+
+	var err error
+	flags.VisitAll(func(flag *pflag.Flag) {
+		value, ok := lookupEnv(EnvName(flag.Name))
+		if !ok || err != nil {
+			return
+		}
+		if setErr := flags.Set(flag.Name, value); setErr != nil {
+			err = fmt.Errorf("%s: %w", EnvName(flag.Name), setErr)
+		}
+	})
+	return err
+}
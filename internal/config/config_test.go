@@ -0,0 +1,235 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brnocorreia/concurrency/internal/entity"
+	"github.com/spf13/pflag"
+)
+
+const (
+	jsonFile = `{
+	"size": 4,
+	"modes": ["mutex", "atomic"],
+	"distribution": "zipf:1.5",
+	"watchdog": "10s",
+	"check_replicas": "5ms",
+	"linearizability": true,
+	"chaos": "delay:1ms,panic:0.1",
+	"blocks": {"health": 50, "hit_time": {"even": "1s", "odd": "250ms"}},
+	"players": [{"power": 10, "sequence": "a.json"}, {}]
+}`
+	yamlFile = `
+size: 4
+modes: [mutex, atomic]
+distribution: zipf:1.5
+watchdog: 10s
+check_replicas: 5ms
+linearizability: true
+chaos: delay:1ms,panic:0.1
+blocks:
+  health: 50
+  hit_time:
+    even: 1s
+    odd: 250ms
+players:
+  - power: 10
+    sequence: a.json
+  - {}
+`
+	tomlFile = `
+size = 4
+modes = ["mutex", "atomic"]
+distribution = "zipf:1.5"
+watchdog = "10s"
+check_replicas = "5ms"
+linearizability = true
+chaos = "delay:1ms,panic:0.1"
+
+[blocks]
+health = 50
+
+[blocks.hit_time]
+even = "1s"
+odd = "250ms"
+
+[[players]]
+power = 10
+sequence = "a.json"
+
+[[players]]
+`
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "game.json", content: jsonFile},
+		{name: "game.yaml", content: yamlFile},
+		{name: "game.yml", content: yamlFile},
+		{name: "game.toml", content: tomlFile},
+	}
+	want := map[string]string{
+		"size":            "4",
+		"mode":            "mutex,atomic",
+		"distribution":    "zipf:1.5",
+		"watchdog":        "10s",
+		"check-replicas":  "5ms",
+		"linearizability": "true",
+		"chaos":           "delay:1ms,panic:0.1",
+		"block-health":    "50",
+		"hit-time-even":   "1s",
+		"hit-time-odd":    "250ms",
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := Load(writeFile(t, tt.name, tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := file.Values(); !reflect.DeepEqual(got, want) {
+				t.Errorf("valores %v, esperado %v", got, want)
+			}
+			if got := file.PlayerPowers(30); !reflect.DeepEqual(got, []int{10, 30}) {
+				t.Errorf("poderes %v, esperado [10 30]", got)
+			}
+			files := file.SequenceFiles(func(id int) string { return "default.json" })
+			if !reflect.DeepEqual(files, []string{"a.json", "default.json"}) {
+				t.Errorf("sequências %v", files)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "game.json", content: `{"sise": 4}`, want: "sise"},
+		{name: "game.yaml", content: "sise: 4\n", want: "sise"},
+		{name: "game.toml", content: "sise = 4\n", want: "sise"},
+		{name: "game.yaml", content: "blocks:\n  hit_time:\n    even: lento\n", want: "lento"},
+		{name: "game.ini", content: "size=4\n", want: "formato desconhecido"},
+	}
+	for _, tt := range tests {
+		_, err := Load(writeFile(t, tt.name, tt.content))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s %q: erro %v, esperado contendo %q", tt.name, tt.content, err, tt.want)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		env   map[string]string
+		size  int
+		mode  string
+		even  time.Duration
+		power int
+	}{
+		{name: "padrões", size: 8, mode: "all", even: 500 * time.Millisecond, power: 30},
+		{name: "arquivo", size: 4, mode: "mutex", even: time.Second, power: 20},
+		{name: "flag sobre arquivo", args: []string{"--size", "6"}, size: 6, mode: "mutex", even: time.Second, power: 20},
+		{
+			name: "ambiente sobre flag e arquivo",
+			args: []string{"--size", "6"},
+			env:  map[string]string{"CONCURRENCY_SIZE": "10", "CONCURRENCY_HIT_TIME_EVEN": "2s"},
+			size: 10, mode: "mutex", even: 2 * time.Second, power: 20,
+		},
+	}
+	file := &File{
+		Size:  ptr(4),
+		Modes: []string{"mutex"},
+		Power: ptr(20),
+		Blocks: Blocks{HitTime: HitTime{
+			Even: ptr(Duration(time.Second)),
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := pflag.NewFlagSet("run", pflag.ContinueOnError)
+			size := flags.Int("size", 8, "")
+			mode := flags.String("mode", "all", "")
+			even := flags.Duration("hit-time-even", 500*time.Millisecond, "")
+			power := flags.Int("power", 30, "")
+			if err := flags.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			source := file
+			if tt.name == "padrões" {
+				source = nil
+			}
+			lookup := func(name string) (string, bool) {
+				value, ok := tt.env[name]
+				return value, ok
+			}
+			if err := Apply(flags, source, lookup); err != nil {
+				t.Fatal(err)
+			}
+			if *size != tt.size || *mode != tt.mode || *even != tt.even || *power != tt.power {
+				t.Errorf("size=%d mode=%s even=%v power=%d, esperado size=%d mode=%s even=%v power=%d",
+					*size, *mode, *even, *power, tt.size, tt.mode, tt.even, tt.power)
+			}
+		})
+	}
+}
+
+func TestApplyInvalidEnv(t *testing.T) {
+	flags := pflag.NewFlagSet("run", pflag.ContinueOnError)
+	flags.Int("size", 8, "")
+	lookup := func(name string) (string, bool) {
+		return "grande", name == "CONCURRENCY_SIZE"
+	}
+	if err := Apply(flags, nil, lookup); err == nil || !strings.Contains(err.Error(), "CONCURRENCY_SIZE") {
+		t.Errorf("erro %v, esperado citando CONCURRENCY_SIZE", err)
+	}
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+func TestBlockTypes(t *testing.T) {
+	content := `
+[blocks]
+mix = "heavy:1,fast:2"
+
+[blocks.types.heavy]
+health = 500
+hit_time = "2s"
+regen = 5
+`
+	file, err := Load(writeFile(t, "game.toml", content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := file.Values()["block-mix"]; got != "heavy:1,fast:2" {
+		t.Errorf("block-mix = %q", got)
+	}
+	want := entity.BlockType{Name: "heavy", Health: 500, HitTime: 2 * time.Second, Regen: 5}
+	if got := file.BlockTypes()["heavy"]; got != want {
+		t.Errorf("tipo %+v, esperado %+v", got, want)
+	}
+}
@@ -9,16 +9,18 @@ import (
 )
 
 var (
-	log *zap.Logger
+	log   *zap.Logger
+	level zap.AtomicLevel
 
 	LOG_OUTPUT = "LOG_OUTPUT"
 	LOG_LEVEL  = "LOG_LEVEL"
 )
 
 func init() {
+	level = zap.NewAtomicLevelAt(getLevelLogs())
 	logConfig := zap.Config{
 		OutputPaths: []string{"log.log", "stdout"},
-		Level:       zap.NewAtomicLevelAt(getLevelLogs()),
+		Level:       level,
 		Encoding:    "console",
 		EncoderConfig: zapcore.EncoderConfig{
 			LevelKey:     "level",
@@ -34,14 +36,26 @@ func init() {
 }
 
 func Info(message string, tags ...zap.Field) {
-	log.Info(message, tags...)
-	log.Sync()
+	write(zap.InfoLevel, message, tags...)
 }
 
 func Error(message string, err error, tags ...zap.Field) {
 	tags = append(tags, zap.NamedError("error", err))
-	log.Info(message, tags...)
-	log.Sync()
+	write(zap.ErrorLevel, message, tags...)
+}
+
+// Só sincroniza o arquivo quando a mensagem foi de fato escrita, para que os
+// logs descartados pelo nível não custem nada
+func write(l zapcore.Level, message string, tags ...zap.Field) {
+	if entry := log.Check(l, message); entry != nil {
+		entry.Write(tags...)
+		log.Sync()
+	}
+}
+
+// Altera o nível dos logs depois da inicialização
+func SetLevel(l zapcore.Level) {
+	level.SetLevel(l)
 }
 
 // func getOutputLogs() string {
@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// Com o nível em ErrorLevel, como no bench, na TUI e no servidor, só os
+// erros continuam sendo escritos
+func TestSetLevel(t *testing.T) {
+	tests := []struct {
+		name  string
+		level zapcore.Level
+		want  []zapcore.Level
+	}{
+		{name: "info", level: zapcore.InfoLevel, want: []zapcore.Level{zapcore.InfoLevel, zapcore.ErrorLevel}},
+		{name: "error", level: zapcore.ErrorLevel, want: []zapcore.Level{zapcore.ErrorLevel}},
+	}
+
+	previous, previousLevel := log, level.Level()
+	defer func() {
+		log = previous
+		SetLevel(previousLevel)
+	}()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(level)
+			log = zap.New(core)
+			SetLevel(tt.level)
+
+			Info("informação")
+			Error("erro", errors.New("falha"))
+
+			var got []zapcore.Level
+			for _, entry := range logs.All() {
+				got = append(got, entry.Level)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("níveis escritos %v, esperado %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("níveis escritos %v, esperado %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package entity
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/brnocorreia/concurrency/internal/tools"
+)
+
+// Mensagem enviada para o ator de um bloco. Com query, o ator apenas
+// responde o seu estado atual; sem query, aplica o ataque do player
+type actorMessage struct {
+	ctx    context.Context
+	query  bool
+	player int
+	damage int
+	reply  chan actorReply
+	// Quando o player enviou a mensagem e se o ator estava ocupado
+	start     time.Time
+	contended bool
+}
+
+// Resposta do ator. Attacked indica que o ataque foi concluído, mesmo que o
+// bloco já estivesse destruído, e Killed que foi esse ataque que o destruiu
+type actorReply struct {
+	Attacked bool
+	Hit      bool
+	Killed   bool
+	Health   int
+	KilledBy int
+	// Métricas do ataque para o player, ou de todo o bloco numa query
+	Locks LockStats
+	// O ataque entrou em pânico dentro do ator, que repassa o pânico ao player
+	Panicked bool
+}
+
+// Implementação dos blocos como atores: cada bloco é uma goroutine dona do
+// seu estado, que recebe os ataques pelo seu canal e responde o resultado.
+// Nenhuma memória é compartilhada, nem mesmo o Player: o ator recebe apenas
+// o id e o dano, e quem atualiza os pontos é a goroutine do próprio player
+type BlockActor struct {
+	Id       int
+	Hit_time time.Duration
+	inbox    chan actorMessage
+	clock    tools.Clock
+	events   Sink
+	chaos    *tools.Chaos
+	// Ataques enviados que o ator ainda não terminou de atender, usado
+	// apenas nas métricas de lock
+	pending atomic.Int64
+	// Estado final do bloco, preenchido quando o tabuleiro é fechado
+	final *actorReply
+}
+
+func NewBlockActor(id int, rules BlockRules, clock tools.Clock, events Sink, chaos *tools.Chaos) *BlockActor {
+	state := newBlockState(id, rules, clock, events)
+	state.goroutine = ActorGoroutine(id)
+	state.chaos = chaos
+	block := &BlockActor{
+		Id:       state.Id,
+		Hit_time: state.Hit_time,
+		inbox:    make(chan actorMessage),
+		clock:    clock,
+		events:   events,
+		chaos:    chaos,
+	}
+	go block.run(state)
+	return block
+}
+
+// Laço do ator. O estado só existe dentro desta goroutine
+func (b *BlockActor) run(state blockState) {
+	for msg := range b.inbox {
+		if msg.query {
+			msg.reply <- actorReply{Health: state.Health, KilledBy: state.KilledBy, Locks: state.locks}
+			continue
+		}
+
+		msg.reply <- b.attack(&state, msg)
+	}
+}
+
+// Aplica o ataque de uma mensagem. Um pânico do modo caos é recuperado para
+// que o ator continue atendendo os outros players
+func (b *BlockActor) attack(state *blockState, msg actorMessage) (reply actorReply) {
+	// Um player local ao ator recebe os pontos, que voltam na resposta
+	player := NewPlayer(msg.player, msg.damage)
+	// Enquanto atende o player, o ator é o dono exclusivo do bloco, e a
+	// espera vai do envio da mensagem até o ator começar a atendê-la. O ator
+	// mede o tempo na linha do player, que está parado esperando a resposta
+	state.acquired(msg.ctx, player, player.Id, msg.start, msg.contended)
+	defer func() {
+		state.released(msg.ctx, player, player.Id)
+		b.pending.Add(-1)
+		if p := recover(); p != nil {
+			if p != tools.ErrChaosPanic {
+				panic(p)
+			}
+			reply = actorReply{Panicked: true, Locks: player.GetLockStats()}
+		}
+	}()
+	hit := state.hit(msg.ctx, player)
+	return actorReply{
+		Attacked: player.GetAttacks() > 0,
+		Hit:      hit,
+		Killed:   player.GetPoints() > 0,
+		Health:   state.Health,
+		KilledBy: state.KilledBy,
+		Locks:    player.GetLockStats(),
+	}
+}
+
+// Envia uma mensagem ao ator e espera a resposta. Retorna false se o
+// contexto for cancelado antes do ator aceitar a mensagem
+func (b *BlockActor) send(ctx context.Context, msg actorMessage) (actorReply, bool) {
+	msg.ctx = ctx
+	msg.reply = make(chan actorReply, 1)
+	select {
+	case b.inbox <- msg:
+	case <-ctx.Done():
+		return actorReply{}, false
+	}
+	// Depois de aceitar, o ator sempre responde: um ataque cancelado termina logo
+	return <-msg.reply, true
+}
+
+func (b *BlockActor) Hit(ctx context.Context, player *Player) bool {
+	// O início do ataque é emitido pela goroutine do player, antes da mensagem
+	if b.events != nil {
+		b.events.Emit(Event{Kind: EventAttempt, Player: player.Id, Block: b.Id, Health: UnknownHealth, Goroutine: PlayerGoroutine(player.Id)})
+	}
+	b.chaos.Delay()
+	// O ataque é disputado quando o ator já tem outro para atender
+	contended := b.pending.Add(1) > 1
+	reply, ok := b.send(ctx, actorMessage{player: player.Id, damage: player.GetDamage(), start: b.clock.Now(ctx), contended: contended})
+	if !ok {
+		b.pending.Add(-1)
+		return false
+	}
+	player.locks.Add(reply.Locks)
+	if reply.Panicked {
+		panic(tools.ErrChaosPanic)
+	}
+	if reply.Attacked {
+		player.AddAttack()
+	}
+	if reply.Killed {
+		player.AddPoint()
+	}
+	return reply.Hit
+}
+
+func (b *BlockActor) query() actorReply {
+	if b.final != nil {
+		return *b.final
+	}
+	reply, _ := b.send(context.Background(), actorMessage{query: true})
+	return reply
+}
+
+func (b *BlockActor) IsAlive() bool {
+	return b.query().Health > 0
+}
+
+func (b *BlockActor) GetId() int {
+	return b.Id
+}
+
+func (b *BlockActor) GetHealth() int {
+	return b.query().Health
+}
+
+func (b *BlockActor) GetKiller() int {
+	return b.query().KilledBy
+}
+
+func (b *BlockActor) LockStats() LockStats {
+	return b.query().Locks
+}
+
+func (b *BlockActor) String() string {
+	return fmt.Sprintf("ID=%d, Health=%d, HitTime=%v", b.Id, b.GetHealth(), b.Hit_time)
+}
+
+// Tabuleiro com um ator por bloco. Os players compartilham apenas os canais
+type actorBoard struct {
+	matrix Matrix
+	close  sync.Once
+}
+
+func NewActorBoard(width, height int, rules BlockRules, clock tools.Clock, events Sink, chaos *tools.Chaos) Board {
+	return &actorBoard{
+		matrix: NewMatrix(width, height, func(id, x, y int) Block {
+			return NewBlockActor(id, rules, clock, events, chaos)
+		}),
+	}
+}
+
+func (b *actorBoard) Block(player *Player, x, y int) Block {
+	return b.matrix[x][y]
+}
+
+func (b *actorBoard) Replicas() []Matrix {
+	return []Matrix{b.matrix}
+}
+
+// Pergunta o estado final a cada ator e encerra as goroutines
+func (b *actorBoard) Close() {
+	b.close.Do(func() {
+		for _, row := range b.matrix {
+			for _, block := range row {
+				actor := block.(*BlockActor)
+				final := actor.query()
+				actor.final = &final
+				close(actor.inbox)
+			}
+		}
+	})
+}
+
+var ActorStrategy = Strategy{
+	Name:  "actors",
+	Label: "ATORES",
+	NewBoard: func(cfg BoardConfig) Board {
+		return NewActorBoard(cfg.Width, cfg.Height, cfg.Blocks, cfg.Clock, cfg.Events, cfg.Chaos)
+	},
+}
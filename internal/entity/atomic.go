@@ -0,0 +1,165 @@
+package entity
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/brnocorreia/concurrency/internal/config/logger"
+	"github.com/brnocorreia/concurrency/internal/tools"
+	"go.uber.org/zap"
+)
+
+// Implementação dos blocos sem lock: a saúde fica num atomic.Int64 e o dano
+// é aplicado com compare-and-swap. Vários players podem estar atacando o
+// mesmo bloco ao mesmo tempo, mas só um CAS vence de cada vez. Junto com a
+// saúde vai o número de CAS que já alteraram o bloco, porque os eventos
+// emitidos depois do CAS podem chegar fora de ordem e a saúde sozinha não os
+// ordena quando o bloco se recupera entre os golpes
+type BlockAtomic struct {
+	Id        int
+	Hit_time  time.Duration
+	blockType BlockType
+	// Saúde nos 32 bits de baixo e número de CAS nos de cima
+	state    atomic.Int64
+	killedBy atomic.Int64
+	clock    tools.Clock
+	events   Sink
+	chaos    *tools.Chaos
+	// Sem lock, cada aquisição é um laço de CAS, disputado quando algum CAS falhou
+	locks atomicLockStats
+}
+
+func NewBlockAtomic(id int, rules BlockRules, clock tools.Clock, events Sink) *BlockAtomic {
+	state := newBlockState(id, rules, clock, events)
+	block := &BlockAtomic{
+		Id:        state.Id,
+		Hit_time:  state.Hit_time,
+		blockType: state.blockType,
+		clock:     clock,
+		events:    events,
+	}
+	block.state.Store(packHealth(state.Health, 0))
+	return block
+}
+
+func packHealth(health, seq int) int64 {
+	return int64(seq)<<32 | int64(uint32(health))
+}
+
+func unpackHealth(state int64) (health, seq int) {
+	return int(uint32(state)), int(state >> 32)
+}
+
+func (b *BlockAtomic) health() int {
+	health, _ := unpackHealth(b.state.Load())
+	return health
+}
+
+func (b *BlockAtomic) Hit(ctx context.Context, player *Player) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	logger.Info("O player tenta acertar o bloco", zap.Int("playerId", player.Id), zap.Int("blockId", b.Id))
+	b.emit(EventAttempt, player.Id, UnknownHealth, 0)
+
+	if b.health() <= 0 {
+		player.AddAttack()
+		return false
+	}
+
+	// O ataque acontece fora de qualquer seção crítica
+	if err := b.clock.Sleep(ctx, b.Hit_time); err != nil {
+		logger.Info("O ataque foi interrompido", zap.Int("playerId", player.Id), zap.Int("blockId", b.Id))
+		return false
+	}
+	// Sem lock, o pânico não deixa nada para trás
+	b.chaos.MaybePanic()
+	player.AddAttack()
+
+	start := b.clock.Now(ctx)
+	contended := false
+	for {
+		// O atraso entre o Load e o CAS aumenta a chance de outro player vencer
+		state := b.state.Load()
+		health, seq := unpackHealth(state)
+		b.chaos.Delay()
+		// Outro player destruiu o bloco enquanto este atacava
+		if health <= 0 {
+			b.acquired(ctx, player, start, contended)
+			return false
+		}
+
+		next := b.blockType.HealthAfterHit(health, player.GetDamage())
+		if !b.state.CompareAndSwap(state, packHealth(next, seq+1)) {
+			// Outro player alterou a saúde entre o Load e o CAS, tenta de novo
+			logger.Info("O player perdeu a disputa pelo bloco", zap.Int("playerId", player.Id), zap.Int("blockId", b.Id))
+			contended = true
+			continue
+		}
+		b.acquired(ctx, player, start, contended)
+
+		logger.Info("O player acertou o bloco", zap.Int("playerId", player.Id), zap.Int("blockId", b.Id))
+		logger.Info("O bloco agora tem", zap.Int("blockId", b.Id), zap.Int("health", next))
+		// Sem lock não há eventos de lock, apenas o resultado do CAS
+		b.emit(EventHit, player.Id, next, seq+1)
+		// Só o CAS que levou a saúde a zero destrói o bloco, então o ponto é de quem o executou
+		if next == 0 {
+			logger.Info("O player destruiu o bloco", zap.Int("playerId", player.Id), zap.Int("blockId", b.Id))
+			player.AddPoint()
+			b.killedBy.Store(int64(player.Id))
+			b.emit(EventKill, player.Id, next, seq+1)
+		}
+		return true
+	}
+}
+
+func (b *BlockAtomic) acquired(ctx context.Context, player *Player, start time.Time, contended bool) {
+	wait := b.clock.Since(ctx, start)
+	b.locks.acquired(wait, contended)
+	player.locks.acquired(wait, contended)
+}
+
+func (b *BlockAtomic) emit(kind EventKind, player, health, seq int) {
+	if b.events != nil {
+		b.events.Emit(Event{Kind: kind, Player: player, Block: b.Id, Health: health, Goroutine: PlayerGoroutine(player), CAS: seq})
+	}
+}
+
+func (b *BlockAtomic) IsAlive() bool {
+	return b.health() > 0
+}
+
+func (b *BlockAtomic) GetId() int {
+	return b.Id
+}
+
+func (b *BlockAtomic) GetHealth() int {
+	return b.health()
+}
+
+func (b *BlockAtomic) GetKiller() int {
+	return int(b.killedBy.Load())
+}
+
+func (b *BlockAtomic) LockStats() LockStats {
+	return b.locks.load()
+}
+
+func (b *BlockAtomic) String() string {
+	return fmt.Sprintf("ID=%d, Health=%d, HitTime=%v", b.Id, b.GetHealth(), b.Hit_time)
+}
+
+var AtomicStrategy = Strategy{
+	Name:  "atomic",
+	Label: "ATOMIC",
+	NewBoard: func(cfg BoardConfig) Board {
+		return NewSharedBoard(NewMatrix(cfg.Width, cfg.Height, func(id, x, y int) Block {
+			block := NewBlockAtomic(id, cfg.Blocks, cfg.Clock, cfg.Events)
+			block.chaos = cfg.Chaos
+			return block
+		}))
+	},
+}
@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"context"
+	"runtime"
+	"sync/atomic"
+	"testing"
+)
+
+// Mede o custo de um ataque em cada estratégia com todos os processadores
+// disputando o mesmo bloco. Com poder zero o bloco nunca é destruído, então
+// todo ataque passa pelo caminho completo
+func BenchmarkBlockHitContended(b *testing.B) {
+	for _, strategy := range Strategies() {
+		b.Run(strategy.Name, func(b *testing.B) {
+			players := runtime.GOMAXPROCS(0)
+			board, _ := newTestBoard(b, strategy, 1, 1, players)
+
+			var ids atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				player := NewPlayer(int(ids.Add(1)), 0)
+				block := board.Block(player, 0, 0)
+				for pb.Next() {
+					block.Hit(context.Background(), player)
+				}
+			})
+		})
+	}
+}
+
+// Mede o custo de um ataque em cada estratégia sem disputa
+func BenchmarkBlockHit(b *testing.B) {
+	for _, strategy := range Strategies() {
+		b.Run(strategy.Name, func(b *testing.B) {
+			board, _ := newTestBoard(b, strategy, 1, 1, 1)
+			player := NewPlayer(1, 0)
+			block := board.Block(player, 0, 0)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				block.Hit(context.Background(), player)
+			}
+		})
+	}
+}
@@ -1,8 +1,8 @@
 package entity
 
 import (
+	"context"
 	"fmt"
-	"sync"
 	"time"
 
 	"github.com/brnocorreia/concurrency/internal/config/logger"
@@ -10,214 +10,235 @@ import (
 	"go.uber.org/zap"
 )
 
-// Implementação dos blocos para MUTEX
-type BlockMutex struct {
+// Block é o contrato comum a todas as implementações de bloco, independente
+// do mecanismo de sincronização usado para proteger o seu estado
+type Block interface {
+	// Ataca o bloco. Retorna false se o bloco já estava destruído ou se o
+	// contexto foi cancelado antes do ataque terminar
+	Hit(ctx context.Context, player *Player) bool
+	IsAlive() bool
+	GetId() int
+	GetHealth() int
+	// Retorna o id do player que destruiu o bloco, ou 0 se ele ainda está vivo
+	GetKiller() int
+	// Retorna as métricas de lock do bloco, somando todos os ataques
+	LockStats() LockStats
+	String() string
+}
+
+// Estado compartilhado por todas as implementações de bloco. Cada estratégia
+// embute o blockState e decide apenas como proteger o acesso a ele
+type blockState struct {
 	Id       int
 	Health   int
 	Hit_time time.Duration
-	mutex    *sync.Mutex
+	KilledBy int
+	// Tipo do bloco, com a saúde inicial e a recuperação depois dos ataques
+	blockType BlockType
+	clock     tools.Clock
+	events    Sink
+	// Réplica do bloco na troca de mensagens, 0 nas outras estratégias
+	replica int
+	// Goroutine dona do estado, vazio quando é a goroutine do player que ataca
+	goroutine string
+	// Falhas do modo caos, nil quando ele está desligado
+	chaos *tools.Chaos
+	// Métricas de lock e o instante da última aquisição
+	locks    LockStats
+	lockedAt time.Time
+	// Instante em que o lock foi liberado pela última vez, que passa para a
+	// goroutine que o pega em seguida
+	releasedAt time.Time
 }
 
-func NewBlockMutex(id int, mutex *sync.Mutex) *BlockMutex {
-	var hitTime time.Duration
-	if id%2 == 0 {
-		hitTime = 500 * time.Millisecond // Duração de 375ms para ids pares
-	} else {
-		hitTime = 125 * time.Millisecond // Duração de 250ms para ids ímpares
-	}
-	return &BlockMutex{
-		Id:       id,
-		Health:   100,
-		Hit_time: hitTime,
-		mutex:    mutex,
-	}
+// Saúde padrão de um bloco no início do jogo
+const InitialHealth = 100
+
+// Regras que definem a saúde inicial e a duração dos ataques de cada bloco.
+// Campos zerados usam os valores de DefaultBlockRules
+type BlockRules struct {
+	Health int
+	// Duração de um ataque nos blocos de id par e de id ímpar
+	EvenHitTime time.Duration
+	OddHitTime  time.Duration
+	// Tipo de cada bloco, na ordem dos ids. Vazio quando todos são do tipo standard
+	Layout []BlockType
+	// Indica os buracos do tabuleiro na ordem dos ids, nil quando não há nenhum
+	Holes []bool
 }
 
-func (b *BlockMutex) Hit(player *Player) bool {
-	b.mutex.Lock()
-	defer b.mutex.Unlock() // Garante que a operação de desbloqueio ocorra após o fim da interação com a memoria
-
-	logger.Info("O player tenta acertar o bloco", zap.Int("playerId", player.Id), zap.Int("blockId", b.Id))
-
-	// TODO: Adicionar contagem de pontos para o ultimo que acertou antes de morrer
-	if b.Health > 0 {
-		time.Sleep(b.Hit_time)
-
-		b.Health -= player.GetDamage()
-		logger.Info("O player acertou o bloco", zap.Int("playerId", player.Id), zap.Int("blockId", b.Id))
-		logger.Info("O bloco agora tem", zap.Int("blockId", b.Id), zap.Int("health", b.Health))
-		if b.Health < 0 {
-			logger.Info("O player destruiu o bloco", zap.Int("playerId", player.Id), zap.Int("blockId", b.Id))
-			player.AddPoint()
-			b.Health = 0
-		}
-		return true
-	}
-	return false
+var DefaultBlockRules = BlockRules{
+	Health:      InitialHealth,
+	EvenHitTime: 500 * time.Millisecond,
+	OddHitTime:  125 * time.Millisecond,
 }
 
-func (b *BlockMutex) IsAlive() bool {
-	b.mutex.Lock()
-	defer b.mutex.Unlock()
-	return b.Health > 0
+// Retorna as regras com os campos zerados preenchidos pelos valores padrão
+func (r BlockRules) WithDefaults() BlockRules {
+	if r.Health == 0 {
+		r.Health = DefaultBlockRules.Health
+	}
+	if r.EvenHitTime == 0 {
+		r.EvenHitTime = DefaultBlockRules.EvenHitTime
+	}
+	if r.OddHitTime == 0 {
+		r.OddHitTime = DefaultBlockRules.OddHitTime
+	}
+	return r
 }
 
-func (b *BlockMutex) String() string {
-	return fmt.Sprintf("ID=%d, Health=%d, HitTime=%v", b.Id, b.Health, b.Hit_time)
+// Duração de um ataque no bloco
+func (r BlockRules) HitTime(id int) time.Duration {
+	if id%2 == 0 {
+		return r.EvenHitTime
+	}
+	return r.OddHitTime
 }
 
-// -------------------------------------------------------------------------------------
-
-// Implementação dos blocos para SEMAPHORE
-type BlockSemaphore struct {
-	Id        int
-	Health    int
-	Hit_time  time.Duration
-	semaphore *tools.Semaphore
+// Duração do ataque mais longo entre todos os blocos das regras
+func (r BlockRules) LongestHit() time.Duration {
+	r = r.WithDefaults()
+	longest := max(r.EvenHitTime, r.OddHitTime)
+	for _, blockType := range r.Layout {
+		longest = max(longest, blockType.HitTime)
+	}
+	return longest
 }
 
-func NewBlockSemaphore(id int, semaphore *tools.Semaphore) *BlockSemaphore {
-	var hitTime time.Duration
-	if id%2 == 0 {
-		hitTime = 500 * time.Millisecond // Duração de 375ms para ids pares
-	} else {
-		hitTime = 125 * time.Millisecond // Duração de 125ms para ids ímpares
+// Tipo do bloco com a saúde e a duração dos ataques já resolvidas pelas regras
+func (r BlockRules) Type(id int) BlockType {
+	// Um buraco é um bloco já sem saúde, que as sequências nunca atacam
+	if id >= 1 && id <= len(r.Holes) && r.Holes[id-1] {
+		return BlockType{Name: HoleBlock}
+	}
+	r = r.WithDefaults()
+	blockType := BlockTypes[StandardBlock]
+	if id >= 1 && id <= len(r.Layout) {
+		blockType = r.Layout[id-1]
+	}
+	if blockType.Health == 0 {
+		blockType.Health = r.Health
 	}
+	if blockType.HitTime == 0 {
+		blockType.HitTime = r.HitTime(id)
+	}
+	return blockType
+}
 
-	return &BlockSemaphore{
+func newBlockState(id int, rules BlockRules, clock tools.Clock, events Sink) blockState {
+	blockType := rules.Type(id)
+	return blockState{
 		Id:        id,
-		Health:    100,
-		Hit_time:  hitTime,
-		semaphore: semaphore,
+		Health:    blockType.Health,
+		Hit_time:  blockType.HitTime,
+		blockType: blockType,
+		clock:     clock,
+		events:    events,
 	}
 }
 
-func (b *BlockSemaphore) Hit(player *Player) bool {
-	b.semaphore.Acquire()
-	defer b.semaphore.Release()
-
-	logger.Info("O player tenta acertar o bloco", zap.Int("playerId", player.Id), zap.Int("blockId", b.Id))
-
-	if b.Health > 0 {
-		time.Sleep(b.Hit_time)
+// Emite um evento do bloco com a saúde atual. Quem chama precisa ter acesso
+// exclusivo ao bloco
+func (b *blockState) emit(kind EventKind, player int) {
+	if b.events != nil {
+		b.emitEvent(Event{Kind: kind, Player: player, Health: b.Health})
+	}
+}
 
-		b.Health -= player.GetDamage()
-		logger.Info("O player acertou o bloco", zap.Int("playerId", player.Id), zap.Int("blockId", b.Id))
-		logger.Info("O bloco agora tem", zap.Int("blockId", b.Id), zap.Int("health", b.Health))
-		if b.Health < 0 {
-			logger.Info("O player destruiu o bloco", zap.Int("playerId", player.Id), zap.Int("blockId", b.Id))
-			player.AddPoint()
-			b.Health = 0
-		}
-		return true
+// Registra que o lock pedido em start foi adquirido e emite o evento. O
+// player é nil quando o lock é feito por uma goroutine auxiliar, que não
+// entra nas métricas dos players. Quem chama precisa ter acesso exclusivo
+func (b *blockState) acquired(ctx context.Context, player *Player, id int, start time.Time, contended bool) {
+	b.clock.Sync(ctx, b.releasedAt)
+	b.lockedAt = b.clock.Now(ctx)
+	wait := b.lockedAt.Sub(start)
+	b.locks.acquired(wait, contended)
+	if player != nil {
+		player.locks.acquired(wait, contended)
 	}
-	return false
+	b.emit(EventLockAcquired, id)
 }
 
-// -------------------------------------------------------------------------------------
+// Registra que o lock foi liberado e emite o evento. Deve ser chamado antes
+// do unlock, ainda com acesso exclusivo
+func (b *blockState) released(ctx context.Context, player *Player, id int) {
+	b.emit(EventLockReleased, id)
+	b.releasedAt = b.clock.Now(ctx)
+	hold := b.releasedAt.Sub(b.lockedAt)
+	b.locks.released(hold)
+	if player != nil {
+		player.locks.released(hold)
+	}
+}
 
-// Implementação dos blocos para troca de mensagens
-type BlockMessage struct {
-	Id       int
-	Health   int
-	Hit_time time.Duration
-	mutex    *tools.PriorityMutex
+// Emite o início de um ataque. Como o player ainda não tem o lock, a saúde
+// do bloco não é lida
+func (b *blockState) attempt(player int) {
+	if b.events != nil {
+		b.emitEvent(Event{Kind: EventAttempt, Player: player, Health: UnknownHealth})
+	}
 }
 
-func NewBlockMessage(id int) *BlockMessage {
-	var hitTime time.Duration
-	if id%2 == 0 {
-		hitTime = 500 * time.Millisecond // Duração de 375ms para ids pares
-	} else {
-		hitTime = 125 * time.Millisecond // Duração de 250ms para ids ímpares
+// Completa o evento com os dados do bloco e o envia para o Sink
+func (b *blockState) emitEvent(event Event) {
+	event.Block = b.Id
+	if event.Replica == 0 {
+		event.Replica = b.replica
+	}
+	if event.Goroutine == "" {
+		event.Goroutine = b.goroutine
 	}
-	return &BlockMessage{
-		Id:       id,
-		Health:   100,
-		Hit_time: hitTime,
-		mutex:    tools.NewPriorityMutex(),
+	if event.Goroutine == "" {
+		event.Goroutine = PlayerGoroutine(event.Player)
 	}
+	b.events.Emit(event)
 }
 
-func (b *BlockMessage) Hit(player *Player, lockSync chan [4]int, updates chan [4]int, x int, y int) bool {
-	b.mutex.Lock(false)
-	// Notifica a outra goroutine que o bloco[x][y] está sendo acertado e precisa ser lockado
-	lockSync <- [4]int{player.Id, 0, x, y}
-
-	// Ao retornar, a função dá unlock na sua matriz e notifica a outra para dar unlock também
-	defer func() {
-		b.mutex.Unlock(false)
-		lockSync <- [4]int{player.Id, 1, x, y}
-	}()
+// Aplica o ataque do player no bloco. Quem chama é responsável por garantir
+// o acesso exclusivo ao bloco durante toda a execução
+func (b *blockState) hit(ctx context.Context, player *Player) bool {
+	// O player pode ter esperado pelo lock enquanto o jogo era interrompido
+	if ctx.Err() != nil {
+		return false
+	}
 
 	logger.Info("O player tenta acertar o bloco", zap.Int("playerId", player.Id), zap.Int("blockId", b.Id))
 
-	if b.Health > 0 {
-		time.Sleep(b.Hit_time)
-
-		b.Health -= player.GetDamage()
-		logger.Info("O player acertou o bloco", zap.Int("playerId", player.Id), zap.Int("blockId", b.Id))
-		logger.Info("O bloco agora tem", zap.Int("blockId", b.Id), zap.Int("health", b.Health))
-		if b.Health < 0 {
-			logger.Info("O player destruiu o bloco", zap.Int("playerId", player.Id), zap.Int("blockId", b.Id))
-			player.AddPoint()
-			b.Health = 0
-		}
-		// Preciso notificar a outra goroutine que o bloco[x][y] foi acertado e precisa atualizar o seu estado
-		updates <- [4]int{player.Id, b.Health, x, y}
-		return true
-	}
-	return false
-}
-
-func SyncLocks(lockSync chan [4]int, matrix_1 MatrixMessage, matrix_2 MatrixMessage) {
-	// defer wg.Done()
-	// Vale lembrar:
-	//   	- lock[0] = playerId
-	//		- lock[1] = 0 se for operação de Lock e 1 se for operação de Unlock
-	//		- lock[2] = Coordenada x do bloco
-	//		- lock[3] = Coordenada y do bloco
-	for lock := range lockSync {
-		id, op, x, y := lock[0], lock[1], lock[2], lock[3]
-		// Se for o player 1, eu preciso lockar na matrix_2
-		if id == 1 {
-			if op == 0 {
-				// Operação de lock
-				matrix_2[x][y].mutex.Lock(true)
-			} else {
-				// Operação de unlock
-				matrix_2[x][y].mutex.Unlock(true)
-			}
-		} else {
-			// Se for o player 2, eu preciso lockar na matrix_1
-			if op == 0 {
-				// Operação de lock
-				matrix_1[x][y].mutex.Lock(true)
-			} else {
-				// Operação de unlock
-				matrix_1[x][y].mutex.Unlock(true)
-			}
-		}
-	}
-}
-
-func UpdateMatrix(updates chan [4]int, matrix_1 MatrixMessage, matrix_2 MatrixMessage) {
-	// defer wg.Done()
-	// Vale lembrar:
-	//   	- update[0] = playerId
-	//		- update[1] = O novo valor da saúde do bloco
-	//		- update[2] = Coordenada x do bloco
-	//		- update[3] = Coordenada y do bloco
-	for update := range updates {
-		id, health, x, y := update[0], update[1], update[2], update[3]
-		if id == 1 {
-			matrix_2[x][y].mutex.Lock(true)
-			matrix_2[x][y].Health = health
-			matrix_2[x][y].mutex.Unlock(true)
-		} else {
-			matrix_1[x][y].mutex.Lock(true)
-			matrix_1[x][y].Health = health
-			matrix_1[x][y].mutex.Unlock(true)
-		}
+	if b.Health <= 0 {
+		player.AddAttack()
+		return false
+	}
+
+	if err := b.clock.Sleep(ctx, b.Hit_time); err != nil {
+		logger.Info("O ataque foi interrompido", zap.Int("playerId", player.Id), zap.Int("blockId", b.Id))
+		return false
+	}
+	// O pânico acontece com o lock pego e antes do dano, então só quem
+	// libera o lock num defer sobrevive a ele
+	b.chaos.MaybePanic()
+	player.AddAttack()
+
+	b.Health = b.blockType.HealthAfterHit(b.Health, player.GetDamage())
+	logger.Info("O player acertou o bloco", zap.Int("playerId", player.Id), zap.Int("blockId", b.Id))
+	logger.Info("O bloco agora tem", zap.Int("blockId", b.Id), zap.Int("health", b.Health))
+	// O ponto vai para o último player que acertou o bloco antes dele morrer
+	if b.Health == 0 {
+		logger.Info("O player destruiu o bloco", zap.Int("playerId", player.Id), zap.Int("blockId", b.Id))
+		player.AddPoint()
+		b.KilledBy = player.Id
+	}
+	b.emit(EventHit, player.Id)
+	// O bloco estava vivo antes do ataque, então foi este ataque que o destruiu
+	if b.Health == 0 {
+		b.emit(EventKill, player.Id)
 	}
+	return true
+}
+
+func (b *blockState) GetId() int {
+	return b.Id
+}
+
+func (b *blockState) String() string {
+	return fmt.Sprintf("ID=%d, Health=%d, HitTime=%v", b.Id, b.Health, b.Hit_time)
 }
@@ -0,0 +1,305 @@
+package entity
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brnocorreia/concurrency/internal/tools"
+)
+
+// Monta um tabuleiro da estratégia com o relógio virtual e fecha ao final do teste
+func newTestBoard(t testing.TB, strategy Strategy, width, height, players int) (Board, *tools.VirtualClock) {
+	t.Helper()
+	clock := tools.NewVirtualClock(time.Unix(0, 0))
+	board := strategy.NewBoard(BoardConfig{
+		Width:   width,
+		Height:  height,
+		Players: players,
+		Clock:   clock,
+	})
+	t.Cleanup(board.Close)
+	return board, clock
+}
+
+func TestBlockHit(t *testing.T) {
+	tests := []struct {
+		name  string
+		power int
+		// Saúde esperada depois de cada ataque
+		healths []int
+	}{
+		{name: "um golpe", power: 100, healths: []int{0}},
+		{name: "dano maior que a saúde", power: 150, healths: []int{0}},
+		{name: "vários golpes", power: 30, healths: []int{70, 40, 10, 0}},
+		{name: "dano exato", power: 50, healths: []int{50, 0}},
+	}
+
+	for _, strategy := range Strategies() {
+		for _, tt := range tests {
+			t.Run(strategy.Name+"/"+tt.name, func(t *testing.T) {
+				board, _ := newTestBoard(t, strategy, 1, 1, 1)
+				player := NewPlayer(1, tt.power)
+				block := board.Block(player, 0, 0)
+
+				for i, health := range tt.healths {
+					if !block.Hit(context.Background(), player) {
+						t.Fatalf("ataque %d: Hit() = false num bloco vivo", i)
+					}
+					if got := block.GetHealth(); got != health {
+						t.Fatalf("ataque %d: GetHealth() = %d, esperado %d", i, got, health)
+					}
+				}
+
+				if block.IsAlive() {
+					t.Error("IsAlive() = true depois do bloco ser destruído")
+				}
+				if got := block.GetKiller(); got != player.Id {
+					t.Errorf("GetKiller() = %d, esperado %d", got, player.Id)
+				}
+				if got := player.GetPoints(); got != 1 {
+					t.Errorf("GetPoints() = %d, esperado 1", got)
+				}
+
+				// Atacar um bloco destruído conta o ataque, mas não acerta
+				if block.Hit(context.Background(), player) {
+					t.Error("Hit() = true num bloco destruído")
+				}
+				if got := player.GetAttacks(); got != len(tt.healths)+1 {
+					t.Errorf("GetAttacks() = %d, esperado %d", got, len(tt.healths)+1)
+				}
+				if got := player.GetPoints(); got != 1 {
+					t.Errorf("GetPoints() = %d depois de atacar um bloco destruído, esperado 1", got)
+				}
+			})
+		}
+	}
+}
+
+func TestBlockHitCancelled(t *testing.T) {
+	for _, strategy := range Strategies() {
+		t.Run(strategy.Name, func(t *testing.T) {
+			board, clock := newTestBoard(t, strategy, 1, 1, 1)
+			player := NewPlayer(1, 100)
+			block := board.Block(player, 0, 0)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			start := clock.Now(context.Background())
+			if block.Hit(ctx, player) {
+				t.Error("Hit() = true com o contexto cancelado")
+			}
+			if !block.IsAlive() || block.GetHealth() != 100 {
+				t.Errorf("o bloco foi alterado por um ataque cancelado: %s", block)
+			}
+			if got := player.GetAttacks(); got != 0 {
+				t.Errorf("GetAttacks() = %d, esperado 0", got)
+			}
+			if got := clock.Since(context.Background(), start); got != 0 {
+				t.Errorf("o relógio avançou %v num ataque cancelado", got)
+			}
+		})
+	}
+}
+
+func TestBlockHitTime(t *testing.T) {
+	tests := []struct {
+		name string
+		y    int
+		want time.Duration
+	}{
+		{name: "id ímpar", y: 0, want: 125 * time.Millisecond},
+		{name: "id par", y: 1, want: 500 * time.Millisecond},
+	}
+
+	for _, strategy := range Strategies() {
+		for _, tt := range tests {
+			t.Run(strategy.Name+"/"+tt.name, func(t *testing.T) {
+				board, clock := newTestBoard(t, strategy, 2, 1, 1)
+				player := NewPlayer(1, 10)
+
+				start := clock.Now(context.Background())
+				board.Block(player, 0, tt.y).Hit(context.Background(), player)
+				if got := clock.Since(context.Background(), start); got != tt.want {
+					t.Errorf("o ataque durou %v, esperado %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+// Saúde e durações configuradas valem em todas as estratégias
+func TestBlockRules(t *testing.T) {
+	rules := BlockRules{Health: 50, EvenHitTime: time.Second, OddHitTime: 2 * time.Second}
+
+	for _, strategy := range Strategies() {
+		t.Run(strategy.Name, func(t *testing.T) {
+			clock := tools.NewVirtualClock(time.Unix(0, 0))
+			board := strategy.NewBoard(BoardConfig{Width: 2, Height: 1, Players: 1, Blocks: rules, Clock: clock})
+			defer board.Close()
+			player := NewPlayer(1, 30)
+
+			if got := board.Block(player, 0, 0).GetHealth(); got != 50 {
+				t.Fatalf("saúde inicial %d, esperado 50", got)
+			}
+			start := clock.Now(context.Background())
+			board.Block(player, 0, 0).Hit(context.Background(), player)
+			board.Block(player, 0, 1).Hit(context.Background(), player)
+			if got := clock.Since(context.Background(), start); got != 3*time.Second {
+				t.Errorf("os ataques duraram %v, esperado 3s", got)
+			}
+			board.Block(player, 0, 0).Hit(context.Background(), player)
+			if player.GetPoints() != 1 || board.Block(player, 0, 0).IsAlive() {
+				t.Errorf("dois ataques de 30 deveriam destruir um bloco com saúde 50")
+			}
+		})
+	}
+}
+
+func TestBlockRulesWithDefaults(t *testing.T) {
+	got := BlockRules{OddHitTime: time.Second}.WithDefaults()
+	want := BlockRules{Health: InitialHealth, EvenHitTime: 500 * time.Millisecond, OddHitTime: time.Second}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WithDefaults() = %+v, esperado %+v", got, want)
+	}
+}
+
+// Vários players atacam o mesmo bloco ao mesmo tempo. Com uma única matriz,
+// o bloco é destruído por exatamente um deles, que ganha o ponto
+func TestBlockConcurrentHits(t *testing.T) {
+	const (
+		numPlayers = 8
+		numAttacks = 20
+	)
+
+	for _, strategy := range Strategies() {
+		t.Run(strategy.Name, func(t *testing.T) {
+			board, _ := newTestBoard(t, strategy, 1, 1, numPlayers)
+
+			players := make([]*Player, numPlayers)
+			var wg sync.WaitGroup
+			for i := range players {
+				players[i] = NewPlayer(i+1, 7)
+				wg.Add(1)
+				go func(player *Player) {
+					defer wg.Done()
+					for j := 0; j < numAttacks; j++ {
+						board.Block(player, 0, 0).Hit(context.Background(), player)
+					}
+				}(players[i])
+			}
+			wg.Wait()
+			board.Close()
+
+			points := 0
+			for _, player := range players {
+				if got := player.GetAttacks(); got != numAttacks {
+					t.Errorf("player %d concluiu %d ataques, esperado %d", player.Id, got, numAttacks)
+				}
+				points += player.GetPoints()
+			}
+
+			replicas := board.Replicas()
+			for i, matrix := range replicas {
+				if health := matrix[0][0].GetHealth(); health < 0 || health > 100 {
+					t.Errorf("réplica %d: saúde %d fora do intervalo [0, 100]", i+1, health)
+				}
+			}
+
+			// As réplicas da troca de mensagens podem divergir, então o
+			// resultado exato só é verificado com uma única matriz
+			if len(replicas) == 1 {
+				block := replicas[0][0][0]
+				if block.IsAlive() || block.GetHealth() != 0 {
+					t.Errorf("o bloco sobreviveu a %d ataques: %s", numPlayers*numAttacks, block)
+				}
+				if points != 1 {
+					t.Errorf("os players ganharam %d pontos, esperado 1", points)
+				}
+				killer := block.GetKiller()
+				if killer == 0 {
+					t.Fatal("o bloco foi destruído sem registrar quem o destruiu")
+				}
+				if got := players[killer-1].GetPoints(); got != 1 {
+					t.Errorf("o player %d destruiu o bloco, mas tem %d pontos", killer, got)
+				}
+			}
+		})
+	}
+}
+
+// Sink que guarda os eventos recebidos
+type recordSink struct {
+	mutex  sync.Mutex
+	events []Event
+}
+
+func (s *recordSink) Emit(event Event) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.events = append(s.events, event)
+}
+
+func TestBlockEvents(t *testing.T) {
+	for _, strategy := range Strategies() {
+		t.Run(strategy.Name, func(t *testing.T) {
+			sink := &recordSink{}
+			board := strategy.NewBoard(BoardConfig{
+				Width:   1,
+				Height:  1,
+				Players: 1,
+				Clock:   tools.NewVirtualClock(time.Unix(0, 0)),
+				Events:  sink,
+			})
+			player := NewPlayer(1, 60)
+			block := board.Block(player, 0, 0)
+			for i := 0; i < 3; i++ {
+				block.Hit(context.Background(), player)
+			}
+			board.Close()
+
+			var attempts, hits, kills, locks int
+			held := false
+			for _, event := range sink.events {
+				if event.Player != player.Id || event.Block != block.GetId() {
+					t.Fatalf("evento de outro player ou bloco: %+v", event)
+				}
+				switch event.Kind {
+				case EventAttempt:
+					attempts++
+					if event.Health != UnknownHealth {
+						t.Errorf("attempt com saúde %d, esperado %d", event.Health, UnknownHealth)
+					}
+				case EventHit:
+					hits++
+					if want := []int{40, 0}[min(hits, 2)-1]; event.Health != want {
+						t.Errorf("acerto %d com saúde %d, esperado %d", hits, event.Health, want)
+					}
+				case EventKill:
+					kills++
+				case EventLockAcquired:
+					if held {
+						t.Error("lock adquirido duas vezes sem ser liberado")
+					}
+					held = true
+					locks++
+				case EventLockReleased:
+					if !held {
+						t.Error("lock liberado sem ter sido adquirido")
+					}
+					held = false
+				}
+			}
+
+			if attempts != 3 || hits != 2 || kills != 1 || held {
+				t.Errorf("%d ataques, %d acertos, %d destruições, lock preso %v; esperado 3, 2, 1, false", attempts, hits, kills, held)
+			}
+			// Apenas o modo atômico não usa nenhum tipo de lock
+			if strategy.Name != AtomicStrategy.Name && locks != 3 {
+				t.Errorf("%d locks adquiridos, esperado 3", locks)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package entity
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tipo de bloco, usado para misturar seções críticas de durações diferentes
+// no mesmo tabuleiro. Health e HitTime zerados seguem as BlockRules
+type BlockType struct {
+	Name    string
+	Health  int
+	HitTime time.Duration
+	// Saúde recuperada depois de cada ataque que o bloco sobrevive, sem
+	// passar da saúde inicial
+	Regen int
+}
+
+// Nome do tipo que segue as BlockRules, que também pode ser escrito como "."
+// nos arquivos de layout
+const StandardBlock = "standard"
+
+// Nome do tipo das posições sem bloco no tabuleiro. Não pode ser usado nos
+// layouts, os buracos vêm da máscara do tabuleiro
+const HoleBlock = "hole"
+
+// Tipos de bloco disponíveis em todos os layouts
+var BlockTypes = map[string]BlockType{
+	StandardBlock: {Name: StandardBlock},
+	"fast":        {Name: "fast", Health: 50, HitTime: 50 * time.Millisecond},
+	"armored":     {Name: "armored", Health: 300, HitTime: time.Second},
+	"regen":       {Name: "regen", Regen: 10},
+}
+
+// Nomes dos tipos de bloco em ordem alfabética
+func BlockTypeNames(types map[string]BlockType) []string {
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Saúde do bloco depois de um ataque com o dano informado. Um bloco
+// destruído não se recupera
+func (t BlockType) HealthAfterHit(health, damage int) int {
+	next := max(health-damage, 0)
+	if next > 0 && t.Regen > 0 {
+		next = min(next+t.Regen, t.Health)
+	}
+	return next
+}
+
+func lookupBlockType(name string, types map[string]BlockType) (BlockType, error) {
+	if name == "." {
+		name = StandardBlock
+	}
+	blockType, ok := types[name]
+	if !ok {
+		return BlockType{}, fmt.Errorf("tipo de bloco desconhecido %q, use %s", name, strings.Join(BlockTypeNames(types), ", "))
+	}
+	blockType.Name = name
+	return blockType, nil
+}
+
+// Lê um layout com o tipo de cada bloco: uma linha por linha da matriz, com
+// os nomes dos tipos separados por espaços. Linhas vazias e as começadas por
+// # são ignoradas. Retorna os tipos na ordem dos ids
+func ParseBlockLayout(r io.Reader, width, height int, types map[string]BlockType) ([]BlockType, error) {
+	layout := make([]BlockType, 0, width*height)
+	rows := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rows++
+		if rows > height {
+			return nil, fmt.Errorf("o layout tem mais de %d linhas", height)
+		}
+		names := strings.Fields(line)
+		if len(names) != width {
+			return nil, fmt.Errorf("linha %d do layout tem %d blocos, esperado %d", rows, len(names), width)
+		}
+		for _, name := range names {
+			blockType, err := lookupBlockType(name, types)
+			if err != nil {
+				return nil, fmt.Errorf("linha %d do layout: %w", rows, err)
+			}
+			layout = append(layout, blockType)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if rows != height {
+		return nil, fmt.Errorf("o layout tem %d linhas, esperado %d", rows, height)
+	}
+	return layout, nil
+}
+
+// Peso de um tipo de bloco na geração aleatória de um layout
+type BlockWeight struct {
+	Type   BlockType
+	Weight int
+}
+
+// Lê uma regra de geração no formato "fast:1,armored:2,standard:3". Um tipo
+// sem peso vale 1
+func ParseBlockMix(rule string, types map[string]BlockType) ([]BlockWeight, error) {
+	var mix []BlockWeight
+	for _, part := range strings.Split(rule, ",") {
+		name, weight, found := strings.Cut(strings.TrimSpace(part), ":")
+		blockType, err := lookupBlockType(name, types)
+		if err != nil {
+			return nil, err
+		}
+		w := 1
+		if found {
+			w, err = strconv.Atoi(weight)
+			if err != nil || w < 0 {
+				return nil, fmt.Errorf("peso inválido %q para o tipo %s", weight, name)
+			}
+		}
+		mix = append(mix, BlockWeight{Type: blockType, Weight: w})
+	}
+	total := 0
+	for _, weight := range mix {
+		total += weight.Weight
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("a regra %q não tem nenhum peso", rule)
+	}
+	return mix, nil
+}
+
+// Sorteia o tipo de cada bloco com a probabilidade proporcional ao peso. A
+// mesma semente gera sempre o mesmo layout
+func GenerateBlockLayout(width, height int, mix []BlockWeight, seed int64) []BlockType {
+	total := 0
+	for _, weight := range mix {
+		total += weight.Weight
+	}
+	rng := rand.New(rand.NewSource(seed))
+	layout := make([]BlockType, width*height)
+	for i := range layout {
+		n := rng.Intn(total)
+		for _, weight := range mix {
+			if n < weight.Weight {
+				layout[i] = weight.Type
+				break
+			}
+			n -= weight.Weight
+		}
+	}
+	return layout
+}
@@ -0,0 +1,128 @@
+package entity
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brnocorreia/concurrency/internal/tools"
+)
+
+func TestHealthAfterHit(t *testing.T) {
+	tests := []struct {
+		name      string
+		blockType BlockType
+		health    int
+		damage    int
+		want      int
+	}{
+		{name: "sem recuperação", blockType: BlockType{Health: 100}, health: 100, damage: 30, want: 70},
+		{name: "destruído", blockType: BlockType{Health: 100}, health: 20, damage: 30, want: 0},
+		{name: "recupera", blockType: BlockType{Health: 100, Regen: 10}, health: 70, damage: 30, want: 50},
+		{name: "não passa da inicial", blockType: BlockType{Health: 100, Regen: 50}, health: 100, damage: 30, want: 100},
+		{name: "destruído não recupera", blockType: BlockType{Health: 100, Regen: 50}, health: 30, damage: 30, want: 0},
+	}
+	for _, tt := range tests {
+		if got := tt.blockType.HealthAfterHit(tt.health, tt.damage); got != tt.want {
+			t.Errorf("%s: saúde %d, esperado %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBlockRulesType(t *testing.T) {
+	rules := BlockRules{Health: 80, Layout: []BlockType{BlockTypes["fast"], BlockTypes["regen"]}}
+	tests := []struct {
+		id   int
+		want BlockType
+	}{
+		{id: 1, want: BlockType{Name: "fast", Health: 50, HitTime: 50 * time.Millisecond}},
+		{id: 2, want: BlockType{Name: "regen", Health: 80, HitTime: 500 * time.Millisecond, Regen: 10}},
+		// Fora do layout o bloco segue as regras
+		{id: 3, want: BlockType{Name: StandardBlock, Health: 80, HitTime: 125 * time.Millisecond}},
+	}
+	for _, tt := range tests {
+		if got := rules.Type(tt.id); got != tt.want {
+			t.Errorf("bloco %d: %+v, esperado %+v", tt.id, got, tt.want)
+		}
+	}
+}
+
+// Os tipos do layout valem em todas as estratégias
+func TestBlockLayout(t *testing.T) {
+	layout, err := ParseBlockLayout(strings.NewReader("# tipos\narmored regen\n"), 2, 1, BlockTypes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := BlockRules{Layout: layout}
+
+	for _, strategy := range Strategies() {
+		t.Run(strategy.Name, func(t *testing.T) {
+			clock := tools.NewVirtualClock(time.Unix(0, 0))
+			board := strategy.NewBoard(BoardConfig{Width: 2, Height: 1, Players: 1, Blocks: rules, Clock: clock})
+			defer board.Close()
+			player := NewPlayer(1, 30)
+
+			armored, regen := board.Block(player, 0, 0), board.Block(player, 0, 1)
+			if got := armored.GetHealth(); got != 300 {
+				t.Fatalf("saúde inicial %d, esperado 300", got)
+			}
+			start := clock.Now(context.Background())
+			armored.Hit(context.Background(), player)
+			if got := clock.Since(context.Background(), start); got != time.Second {
+				t.Errorf("o ataque durou %v, esperado 1s", got)
+			}
+			regen.Hit(context.Background(), player)
+			if got := regen.GetHealth(); got != 80 {
+				t.Errorf("saúde depois do ataque %d, esperado 80", got)
+			}
+		})
+	}
+}
+
+func TestParseBlockLayoutErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout string
+		want   string
+	}{
+		{name: "linha curta", layout: "fast\nfast fast\n", want: "linha 1"},
+		{name: "poucas linhas", layout: "fast fast\n", want: "1 linhas"},
+		{name: "muitas linhas", layout: ". .\n. .\n. .\n", want: "mais de 2"},
+		{name: "tipo desconhecido", layout: "fast slow\n. .\n", want: `"slow"`},
+	}
+	for _, tt := range tests {
+		_, err := ParseBlockLayout(strings.NewReader(tt.layout), 2, 2, BlockTypes)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: erro %v, esperado contendo %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestGenerateBlockLayout(t *testing.T) {
+	mix, err := ParseBlockMix("fast:1, armored, standard:0", BlockTypes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layout := GenerateBlockLayout(8, 8, mix, 42)
+	counts := make(map[string]int)
+	for _, blockType := range layout {
+		counts[blockType.Name]++
+	}
+	if counts["fast"] == 0 || counts["armored"] == 0 || counts[StandardBlock] != 0 || len(layout) != 64 {
+		t.Errorf("layout com %v", counts)
+	}
+
+	again := GenerateBlockLayout(8, 8, mix, 42)
+	for i := range layout {
+		if layout[i] != again[i] {
+			t.Fatalf("a mesma semente gerou layouts diferentes no bloco %d", i+1)
+		}
+	}
+
+	for _, rule := range []string{"fast:-1", "fast:0", "slow:1"} {
+		if _, err := ParseBlockMix(rule, BlockTypes); err == nil {
+			t.Errorf("regra %q aceita", rule)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package entity
+
+import "github.com/brnocorreia/concurrency/internal/tools"
+
+// Board é o tabuleiro onde o jogo acontece. Ele decide qual bloco cada player
+// enxerga e guarda as matrizes usadas pela estratégia
+type Board interface {
+	// Retorna o bloco (x, y) na visão do player
+	Block(player *Player, x, y int) Block
+	// Retorna as matrizes do tabuleiro. Estratégias de memória compartilhada
+	// possuem uma única matriz, enquanto a troca de mensagens possui uma réplica por player
+	Replicas() []Matrix
+	// Encerra as goroutines auxiliares do tabuleiro, se houver, e aguarda que
+	// elas terminem. Pode ser chamado mais de uma vez
+	Close()
+}
+
+// Parâmetros usados pelas estratégias para montar o tabuleiro
+type BoardConfig struct {
+	Width  int
+	Height int
+	// Número de players que vão atacar o tabuleiro
+	Players int
+	// Saúde inicial e duração dos ataques dos blocos
+	Blocks BlockRules
+	// Relógio usado pelos blocos para simular a duração dos ataques
+	Clock tools.Clock
+	// Recebe os eventos dos blocos. Pode ser nil
+	Events Sink
+	// Falhas injetadas nos locks, nas mensagens e nos ataques. Pode ser nil
+	Chaos *tools.Chaos
+}
+
+// Tabuleiro com uma única matriz compartilhada por todos os players
+type sharedBoard struct {
+	matrix Matrix
+}
+
+func NewSharedBoard(matrix Matrix) Board {
+	return &sharedBoard{matrix: matrix}
+}
+
+func (b *sharedBoard) Block(player *Player, x, y int) Block {
+	return b.matrix[x][y]
+}
+
+func (b *sharedBoard) Replicas() []Matrix {
+	return []Matrix{b.matrix}
+}
+
+func (b *sharedBoard) Close() {}
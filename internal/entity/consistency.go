@@ -0,0 +1,106 @@
+package entity
+
+import (
+	"fmt"
+	"io"
+)
+
+// Última escrita na saúde de um bloco de uma réplica: o ataque do próprio
+// player ou a cópia feita pelo UpdateMatrix
+type Update struct {
+	Player    int    `json:"player"`
+	Health    int    `json:"health"`
+	Goroutine string `json:"goroutine"`
+}
+
+// Blocos que sabem qual foi a última escrita na sua saúde
+type updateTracker interface {
+	// Retorna a última escrita, ou nil se o bloco nunca foi atacado
+	LastUpdate() *Update
+}
+
+// Estado de um bloco numa réplica
+type ReplicaCell struct {
+	Replica int     `json:"replica"`
+	Health  int     `json:"health"`
+	Last    *Update `json:"last_update,omitempty"`
+}
+
+// Bloco cuja saúde não é a mesma em todas as réplicas. Final indica que a
+// divergência foi encontrada no fim do jogo, e não pela verificação contínua
+type Divergence struct {
+	Block    int           `json:"block"`
+	Row      int           `json:"row"`
+	Col      int           `json:"col"`
+	Final    bool          `json:"final"`
+	Replicas []ReplicaCell `json:"replicas"`
+}
+
+// Tabuleiros que conseguem comparar as réplicas com o jogo em andamento
+type ReplicaChecker interface {
+	// Compara os blocos que não têm atualizações pendentes. Um bloco com uma
+	// cópia ainda a caminho pode divergir sem que isso seja um erro
+	CheckReplicas() []Divergence
+}
+
+// Compara a saúde de cada bloco em todas as réplicas. Só faz sentido depois
+// que as goroutines do tabuleiro terminaram de aplicar as atualizações
+func CompareReplicas(replicas []Matrix) []Divergence {
+	if len(replicas) < 2 {
+		return nil
+	}
+	var divergences []Divergence
+	for i, row := range replicas[0] {
+		for j := range row {
+			cells := make([]ReplicaCell, len(replicas))
+			for r, matrix := range replicas {
+				cells[r] = replicaCell(r+1, matrix[i][j])
+			}
+			if divergence, ok := diverge(i, j, replicas[0][i][j].GetId(), cells); ok {
+				divergence.Final = true
+				divergences = append(divergences, divergence)
+			}
+		}
+	}
+	return divergences
+}
+
+func replicaCell(replica int, block Block) ReplicaCell {
+	cell := ReplicaCell{Replica: replica, Health: block.GetHealth()}
+	if tracker, ok := block.(updateTracker); ok {
+		cell.Last = tracker.LastUpdate()
+	}
+	return cell
+}
+
+func diverge(row, col, id int, cells []ReplicaCell) (Divergence, bool) {
+	for _, cell := range cells[1:] {
+		if cell.Health != cells[0].Health {
+			return Divergence{Block: id, Row: row, Col: col, Replicas: cells}, true
+		}
+	}
+	return Divergence{}, false
+}
+
+func (d Divergence) String() string {
+	s := fmt.Sprintf("bloco %d [%d][%d]:", d.Block, d.Row, d.Col)
+	for _, cell := range d.Replicas {
+		s += fmt.Sprintf(" réplica %d com saúde %d", cell.Replica, cell.Health)
+		if cell.Last != nil {
+			s += fmt.Sprintf(" (última atualização: %d do player %d por %s)", cell.Last.Health, cell.Last.Player, cell.Last.Goroutine)
+		}
+		s += ";"
+	}
+	return s[:len(s)-1]
+}
+
+// Imprime as divergências, uma por linha
+func FprintDivergences(w io.Writer, divergences []Divergence) {
+	for _, divergence := range divergences {
+		when := "no fim do jogo"
+		if !divergence.Final {
+			when = "durante o jogo"
+		}
+		fmt.Fprintf(w, "%s, %s\n", divergence, when)
+	}
+}
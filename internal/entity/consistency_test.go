@@ -0,0 +1,73 @@
+package entity
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestCompareReplicas(t *testing.T) {
+	board, _ := newTestBoard(t, MessageStrategy, 2, 1, 2)
+	players := []*Player{NewPlayer(1, 30), NewPlayer(2, 20)}
+	board.Block(players[0], 0, 0).Hit(context.Background(), players[0])
+	board.Block(players[1], 0, 1).Hit(context.Background(), players[1])
+	board.Close()
+
+	replicas := board.Replicas()
+	if divergences := CompareReplicas(replicas); divergences != nil {
+		t.Fatalf("CompareReplicas() = %+v com as réplicas iguais", divergences)
+	}
+
+	// Uma atualização perdida deixa a réplica 2 com a saúde antiga
+	stale := blockMessageAt(replicas[1], 0, 0)
+	stale.Health = 100
+	stale.last = nil
+
+	want := []Divergence{{
+		Block: 1,
+		Row:   0,
+		Col:   0,
+		Final: true,
+		Replicas: []ReplicaCell{
+			{Replica: 1, Health: 70, Last: &Update{Player: 1, Health: 70, Goroutine: "player-1"}},
+			{Replica: 2, Health: 100},
+		},
+	}}
+	got := CompareReplicas(replicas)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CompareReplicas() = %+v, esperado %+v", got, want)
+	}
+
+	// A verificação contínua encontra a mesma divergência
+	want[0].Final = false
+	checked := board.(ReplicaChecker).CheckReplicas()
+	if !reflect.DeepEqual(checked, want) {
+		t.Errorf("CheckReplicas() = %+v, esperado %+v", checked, want)
+	}
+
+	// Com uma atualização a caminho, o bloco ainda pode divergir
+	stale.pending.Add(1)
+	if checked := board.(ReplicaChecker).CheckReplicas(); checked != nil {
+		t.Errorf("CheckReplicas() = %+v com uma atualização pendente", checked)
+	}
+
+	if got := CompareReplicas(replicas[:1]); got != nil {
+		t.Errorf("CompareReplicas() com uma réplica = %+v", got)
+	}
+}
+
+func TestDivergenceString(t *testing.T) {
+	divergence := Divergence{
+		Block: 6,
+		Row:   1,
+		Col:   2,
+		Replicas: []ReplicaCell{
+			{Replica: 1, Health: 40, Last: &Update{Player: 2, Health: 40, Goroutine: UpdateMatrixGoroutine}},
+			{Replica: 2, Health: 70},
+		},
+	}
+	want := "bloco 6 [1][2]: réplica 1 com saúde 40 (última atualização: 40 do player 2 por update-matrix); réplica 2 com saúde 70"
+	if got := divergence.String(); got != want {
+		t.Errorf("String() = %q, esperado %q", got, want)
+	}
+}
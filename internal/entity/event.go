@@ -0,0 +1,74 @@
+package entity
+
+import "fmt"
+
+// Tipo de um evento emitido pelos blocos durante o jogo
+type EventKind string
+
+const (
+	// O player vai atacar o bloco, antes de esperar pelo lock
+	EventAttempt EventKind = "attempt"
+	// Uma goroutine auxiliar vai esperar pelo lock do bloco em nome do player
+	EventLockWait EventKind = "lock-wait"
+	// O player conseguiu acesso exclusivo ao bloco
+	EventLockAcquired EventKind = "lock-acquired"
+	// O player liberou o bloco
+	EventLockReleased EventKind = "lock-released"
+	// O ataque do player tirou saúde do bloco
+	EventHit EventKind = "hit"
+	// O ataque do player destruiu o bloco
+	EventKill EventKind = "kill"
+	// A saúde que o player deixou no bloco foi copiada para outra réplica
+	EventUpdatePropagated EventKind = "update-propagated"
+)
+
+// Saúde informada nos eventos emitidos sem acesso ao estado do bloco
+const UnknownHealth = -1
+
+// Evento emitido por um bloco. Health é a saúde do bloco no momento do
+// evento, ou UnknownHealth nos ataques que ainda não conseguiram o lock
+type Event struct {
+	Kind   EventKind `json:"kind"`
+	Player int       `json:"player"`
+	Block  int       `json:"block"`
+	Health int       `json:"health"`
+	// Réplica onde o evento aconteceu, 0 quando o tabuleiro tem uma única matriz
+	Replica int `json:"replica,omitempty"`
+	// Goroutine que emitiu o evento: "player-N", "actor-N", "sync-locks-N"
+	// ou "update-matrix"
+	Goroutine string `json:"goroutine"`
+	// Número do CAS que deixou a saúde do evento no bloco, contado a partir
+	// de 1 no modo atômico. Nas outras estratégias é 0, porque o lock já
+	// emite os eventos de cada bloco na ordem em que aconteceram
+	CAS int `json:"cas,omitempty"`
+}
+
+// Sink recebe os eventos do jogo. Emit é chamado por todos os players ao
+// mesmo tempo e, muitas vezes, com o lock do bloco, então precisa ser seguro
+// para uso concorrente e retornar rápido
+type Sink interface {
+	Emit(event Event)
+}
+
+// Repassa cada evento para todos os Sinks da lista
+type Sinks []Sink
+
+func (s Sinks) Emit(event Event) {
+	for _, sink := range s {
+		sink.Emit(event)
+	}
+}
+
+func PlayerGoroutine(player int) string {
+	return fmt.Sprintf("player-%d", player)
+}
+
+func ActorGoroutine(block int) string {
+	return fmt.Sprintf("actor-%d", block)
+}
+
+func SyncLocksGoroutine(player int) string {
+	return fmt.Sprintf("sync-locks-%d", player)
+}
+
+const UpdateMatrixGoroutine = "update-matrix"
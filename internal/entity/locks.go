@@ -0,0 +1,103 @@
+package entity
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Limites das faixas do histograma de espera pelos locks. A faixa i conta as
+// esperas de até WaitBuckets[i], e uma faixa extra conta as maiores
+var WaitBuckets = [...]time.Duration{
+	time.Microsecond,
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+}
+
+// Métricas de lock de um bloco ou de um player, medidas com o relógio do jogo.
+// Uma aquisição é disputada quando encontrou o lock ocupado e precisou esperar
+type LockStats struct {
+	Acquisitions  int
+	Contended     int
+	Wait          time.Duration
+	Hold          time.Duration
+	MaxWait       time.Duration
+	WaitHistogram [len(WaitBuckets) + 1]int
+}
+
+// Faixa do histograma onde cai uma espera
+func waitBucket(wait time.Duration) int {
+	for i, limit := range WaitBuckets {
+		if wait <= limit {
+			return i
+		}
+	}
+	return len(WaitBuckets)
+}
+
+func (s *LockStats) acquired(wait time.Duration, contended bool) {
+	s.Acquisitions++
+	if contended {
+		s.Contended++
+	}
+	s.Wait += wait
+	s.MaxWait = max(s.MaxWait, wait)
+	s.WaitHistogram[waitBucket(wait)]++
+}
+
+func (s *LockStats) released(hold time.Duration) {
+	s.Hold += hold
+}
+
+// Soma as métricas de outro bloco ou player
+func (s *LockStats) Add(other LockStats) {
+	s.Acquisitions += other.Acquisitions
+	s.Contended += other.Contended
+	s.Wait += other.Wait
+	s.Hold += other.Hold
+	s.MaxWait = max(s.MaxWait, other.MaxWait)
+	for i, count := range other.WaitHistogram {
+		s.WaitHistogram[i] += count
+	}
+}
+
+// Versão de LockStats que pode ser atualizada por vários players ao mesmo
+// tempo, usada pelos blocos que não têm lock
+type atomicLockStats struct {
+	acquisitions  atomic.Int64
+	contended     atomic.Int64
+	wait          atomic.Int64
+	maxWait       atomic.Int64
+	waitHistogram [len(WaitBuckets) + 1]atomic.Int64
+}
+
+func (s *atomicLockStats) acquired(wait time.Duration, contended bool) {
+	s.acquisitions.Add(1)
+	if contended {
+		s.contended.Add(1)
+	}
+	s.wait.Add(int64(wait))
+	for {
+		current := s.maxWait.Load()
+		if int64(wait) <= current || s.maxWait.CompareAndSwap(current, int64(wait)) {
+			break
+		}
+	}
+	s.waitHistogram[waitBucket(wait)].Add(1)
+}
+
+func (s *atomicLockStats) load() LockStats {
+	stats := LockStats{
+		Acquisitions: int(s.acquisitions.Load()),
+		Contended:    int(s.contended.Load()),
+		Wait:         time.Duration(s.wait.Load()),
+		MaxWait:      time.Duration(s.maxWait.Load()),
+	}
+	for i := range s.waitHistogram {
+		stats.WaitHistogram[i] = int(s.waitHistogram[i].Load())
+	}
+	return stats
+}
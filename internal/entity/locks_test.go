@@ -0,0 +1,64 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWaitBucket(t *testing.T) {
+	tests := []struct {
+		wait time.Duration
+		want int
+	}{
+		{wait: 0, want: 0},
+		{wait: time.Microsecond, want: 0},
+		{wait: time.Microsecond + 1, want: 1},
+		{wait: 5 * time.Millisecond, want: 4},
+		{wait: time.Second, want: len(WaitBuckets) - 1},
+		{wait: time.Minute, want: len(WaitBuckets)},
+	}
+
+	for _, tt := range tests {
+		if got := waitBucket(tt.wait); got != tt.want {
+			t.Errorf("waitBucket(%v) = %d, esperado %d", tt.wait, got, tt.want)
+		}
+	}
+}
+
+func TestLockStatsAdd(t *testing.T) {
+	var a, b LockStats
+	a.acquired(time.Millisecond, false)
+	a.released(2 * time.Millisecond)
+	b.acquired(time.Second, true)
+	b.acquired(0, false)
+
+	a.Add(b)
+	if a.Acquisitions != 3 || a.Contended != 1 || a.Wait != time.Second+time.Millisecond || a.Hold != 2*time.Millisecond || a.MaxWait != time.Second {
+		t.Errorf("soma inesperada: %+v", a)
+	}
+	if a.WaitHistogram[0] != 1 || a.WaitHistogram[3] != 1 || a.WaitHistogram[len(WaitBuckets)-1] != 1 {
+		t.Errorf("histograma inesperado: %v", a.WaitHistogram)
+	}
+}
+
+// A versão atômica deve chegar às mesmas métricas com vários players ao mesmo tempo
+func TestAtomicLockStats(t *testing.T) {
+	var stats atomicLockStats
+	done := make(chan struct{})
+	for i := range 8 {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for j := range 100 {
+				stats.acquired(time.Duration(i*100+j)*time.Millisecond, j%2 == 0)
+			}
+		}()
+	}
+	for range 8 {
+		<-done
+	}
+
+	got := stats.load()
+	if got.Acquisitions != 800 || got.Contended != 400 || got.MaxWait != 799*time.Millisecond {
+		t.Errorf("métricas inesperadas: %+v", got)
+	}
+}
@@ -2,31 +2,30 @@ package entity
 
 import (
 	"fmt"
-	"sync"
+	"io"
+	"os"
 
 	"math/rand"
-
-	"github.com/brnocorreia/concurrency/internal/tools"
 )
 
-// Implementação da matriz para MUTEX
-type MatrixMutex [][]*BlockMutex
+// Matriz de blocos, comum a todas as estratégias
+type Matrix [][]Block
 
-func NewMatrixMutex(width, height int) MatrixMutex {
-	matrix := make(MatrixMutex, height)
+func NewMatrix(width, height int, newBlock func(id, x, y int) Block) Matrix {
+	matrix := make(Matrix, height)
 
 	id := 1
 	for i := range matrix {
-		matrix[i] = make([]*BlockMutex, width)
+		matrix[i] = make([]Block, width)
 		for j := range matrix[i] {
-			matrix[i][j] = NewBlockMutex(id, &sync.Mutex{})
+			matrix[i][j] = newBlock(id, i, j)
 			id++
 		}
 	}
 	return matrix
 }
 
-func (m MatrixMutex) GetRandomBlock() *BlockMutex {
+func (m Matrix) GetRandomBlock() Block {
 	rows := len(m)
 	cols := len(m[0])
 	row := rand.Intn(rows)
@@ -34,83 +33,45 @@ func (m MatrixMutex) GetRandomBlock() *BlockMutex {
 	return m[row][col]
 }
 
-func PrintBlocksMutex(m MatrixMutex) {
-	for _, row := range m {
-		for _, block := range row {
-			fmt.Printf("%3d ", block.Health)
+// Retorna a saúde de cada bloco da matriz
+func (m Matrix) Healths() [][]int {
+	healths := make([][]int, len(m))
+	for i, row := range m {
+		healths[i] = make([]int, len(row))
+		for j, block := range row {
+			healths[i][j] = block.GetHealth()
 		}
-		fmt.Println()
 	}
+	return healths
 }
 
-// -------------------------------------------------------------------------------------
-
-// Implementação da matriz para SEMAPHORE
-type MatrixSemaphore [][]*BlockSemaphore
-
-func NewMatrixSemaphore(width, height int) MatrixSemaphore {
-	matrix := make(MatrixSemaphore, height)
-
-	id := 1
-	for i := range matrix {
-		matrix[i] = make([]*BlockSemaphore, width)
-		for j := range matrix[i] {
-			matrix[i][j] = NewBlockSemaphore(id, tools.NewSemaphore())
-			id++
+// Retorna o id do player que destruiu cada bloco da matriz, ou 0 se ele está vivo
+func (m Matrix) Kills() [][]int {
+	kills := make([][]int, len(m))
+	for i, row := range m {
+		kills[i] = make([]int, len(row))
+		for j, block := range row {
+			kills[i][j] = block.GetKiller()
 		}
 	}
-	return matrix
+	return kills
 }
 
-func (m MatrixSemaphore) GetRandomBlock() *BlockSemaphore {
-	rows := len(m)
-	cols := len(m[0])
-	row := rand.Intn(rows)
-	col := rand.Intn(cols)
-	return m[row][col]
-}
-
-func PrintBlocksSemaphore(m MatrixSemaphore) {
-	for _, row := range m {
-		for _, block := range row {
-			fmt.Printf("%3d ", block.Health)
-		}
-		fmt.Println()
-	}
-}
-
-// -------------------------------------------------------------------------------------
-
-// Implementação da matriz para TROCA DE MENSAGENS
-type MatrixMessage [][]*BlockMessage
-
-func NewMatrixMessage(width, height int) MatrixMessage {
-	matrix := make(MatrixMessage, height)
-
-	id := 1
-	for i := range matrix {
-		matrix[i] = make([]*BlockMessage, width)
-		for j := range matrix[i] {
-			matrix[i][j] = NewBlockMessage(id)
-			id++
-		}
-	}
-	return matrix
-}
-
-func (m MatrixMessage) GetRandomBlock() *BlockMessage {
-	rows := len(m)
-	cols := len(m[0])
-	row := rand.Intn(rows)
-	col := rand.Intn(cols)
-	return m[row][col]
+func PrintBlocks(m Matrix, rules BlockRules) {
+	FprintBlocks(os.Stdout, m, rules)
 }
 
-func PrintBlocksMessage(m MatrixMessage) {
+// Imprime a saúde de cada bloco da matriz em w. Os buracos ficam em branco,
+// para não serem confundidos com os blocos destruídos
+func FprintBlocks(w io.Writer, m Matrix, rules BlockRules) {
 	for _, row := range m {
 		for _, block := range row {
-			fmt.Printf("%3d ", block.Health)
+			if rules.Type(block.GetId()).Name == HoleBlock {
+				fmt.Fprint(w, "    ")
+				continue
+			}
+			fmt.Fprintf(w, "%3d ", block.GetHealth())
 		}
-		fmt.Println()
+		fmt.Fprintln(w)
 	}
 }
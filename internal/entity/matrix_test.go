@@ -0,0 +1,63 @@
+package entity
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/brnocorreia/concurrency/internal/tools"
+)
+
+func TestNewMatrix(t *testing.T) {
+	clock := tools.NewVirtualClock(time.Unix(0, 0))
+	matrix := NewMatrix(3, 2, func(id, x, y int) Block {
+		return NewBlockAtomic(id, DefaultBlockRules, clock, nil)
+	})
+
+	if len(matrix) != 2 || len(matrix[0]) != 3 {
+		t.Fatalf("a matriz tem %dx%d blocos, esperado 2x3", len(matrix), len(matrix[0]))
+	}
+
+	// Os ids são sequenciais, linha a linha, começando em 1
+	id := 1
+	for i, row := range matrix {
+		for j, block := range row {
+			if block.GetId() != id {
+				t.Errorf("bloco [%d][%d] tem id %d, esperado %d", i, j, block.GetId(), id)
+			}
+			id++
+		}
+	}
+
+	for _, row := range matrix.Healths() {
+		for _, health := range row {
+			if health != 100 {
+				t.Errorf("saúde inicial %d, esperado 100", health)
+			}
+		}
+	}
+	for _, row := range matrix.Kills() {
+		for _, killer := range row {
+			if killer != 0 {
+				t.Errorf("bloco vivo com killer %d", killer)
+			}
+		}
+	}
+}
+
+// Os buracos ficam em branco e os blocos destruídos aparecem com saúde 0
+func TestFprintBlocks(t *testing.T) {
+	clock := tools.NewVirtualClock(time.Unix(0, 0))
+	rules := DefaultBlockRules
+	rules.Holes = []bool{false, true, false}
+	matrix := NewMatrix(3, 1, func(id, x, y int) Block {
+		return NewBlockAtomic(id, rules, clock, nil)
+	})
+	matrix[0][2].(*BlockAtomic).state.Store(0)
+
+	var out bytes.Buffer
+	FprintBlocks(&out, matrix, rules)
+	if want := "100 " + "    " + "  0 \n"; out.String() != want {
+		t.Errorf("FprintBlocks() = %q, esperado %q", out.String(), want)
+	}
+}
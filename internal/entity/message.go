@@ -0,0 +1,329 @@
+package entity
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/brnocorreia/concurrency/internal/tools"
+)
+
+// Implementação dos blocos para troca de mensagens
+type BlockMessage struct {
+	blockState
+	x        int
+	y        int
+	mutex    tools.PriorityLocker
+	lockSync chan<- [5]int
+	updates  chan<- [5]int
+	// Última escrita na saúde do bloco nesta réplica
+	last *Update
+	// Atualizações do bloco enviadas e ainda não copiadas para todas as
+	// réplicas, compartilhado pelo bloco em todas elas. Pode ser nil
+	pending *atomic.Int32
+}
+
+func NewBlockMessage(id, x, y int, lockSync chan<- [5]int, updates chan<- [5]int, rules BlockRules, clock tools.Clock, events Sink) *BlockMessage {
+	return &BlockMessage{
+		blockState: newBlockState(id, rules, clock, events),
+		x:          x,
+		y:          y,
+		mutex:      tools.NewPriorityMutex(),
+		lockSync:   lockSync,
+		updates:    updates,
+	}
+}
+
+func (b *BlockMessage) Hit(ctx context.Context, player *Player) bool {
+	b.attempt(player.Id)
+	start := b.clock.Now(ctx)
+	contended := !b.mutex.TryLock(false)
+	if contended {
+		b.mutex.Lock(false)
+	}
+	b.acquired(ctx, player, player.Id, start, contended)
+	// Notifica a outra goroutine que o bloco[x][y] está sendo acertado e precisa ser lockado
+	b.lockSync <- [5]int{player.Id, 0, b.x, b.y, b.instant(ctx)}
+
+	// Ao retornar, a função dá unlock na sua matriz e notifica a outra para dar unlock também
+	defer func() {
+		b.released(ctx, player, player.Id)
+		b.mutex.Unlock(false)
+		b.lockSync <- [5]int{player.Id, 1, b.x, b.y, b.instant(ctx)}
+	}()
+
+	if !b.hit(ctx, player) {
+		return false
+	}
+	b.last = &Update{Player: player.Id, Health: b.Health, Goroutine: PlayerGoroutine(player.Id)}
+	// Preciso notificar a outra goroutine que o bloco[x][y] foi acertado e precisa atualizar o seu estado
+	if b.pending != nil {
+		b.pending.Add(1)
+	}
+	b.updates <- [5]int{player.Id, b.Health, b.x, b.y, b.instant(ctx)}
+	return true
+}
+
+func (b *BlockMessage) IsAlive() bool {
+	return b.GetHealth() > 0
+}
+
+func (b *BlockMessage) GetHealth() int {
+	b.mutex.Lock(false)
+	defer b.mutex.Unlock(false)
+	return b.Health
+}
+
+func (b *BlockMessage) GetKiller() int {
+	b.mutex.Lock(false)
+	defer b.mutex.Unlock(false)
+	return b.KilledBy
+}
+
+func (b *BlockMessage) LastUpdate() *Update {
+	b.mutex.Lock(false)
+	defer b.mutex.Unlock(false)
+	return b.last
+}
+
+func (b *BlockMessage) LockStats() LockStats {
+	b.mutex.Lock(false)
+	defer b.mutex.Unlock(false)
+	return b.locks
+}
+
+// Emite um evento de lock feito por SyncLocks em nome do player
+func (b *BlockMessage) emitSync(kind EventKind, player int) {
+	if b.events != nil {
+		b.emitEvent(Event{Kind: kind, Player: player, Health: b.Health, Goroutine: SyncLocksGoroutine(player)})
+	}
+}
+
+// Emite a espera de uma goroutine auxiliar pelo lock. Sem o lock, a saúde
+// do bloco não é lida
+func (b *BlockMessage) emitWait(player int, goroutine string) {
+	if b.events != nil {
+		b.emitEvent(Event{Kind: EventLockWait, Player: player, Health: UnknownHealth, Goroutine: goroutine})
+	}
+}
+
+// Instante da goroutine do contexto em nanossegundos, que vai junto com as
+// mensagens para a goroutine que as recebe
+func (b *BlockMessage) instant(ctx context.Context) int {
+	return int(b.clock.Now(ctx).UnixNano())
+}
+
+// Sincroniza a goroutine auxiliar com o instante em que a mensagem foi enviada
+func (b *BlockMessage) receive(ctx context.Context, instant int) {
+	b.clock.Sync(ctx, time.Unix(0, int64(instant)))
+}
+
+// Faz o lock de prioridade alta de uma goroutine auxiliar. Ele entra nas
+// métricas do bloco, mas não nas dos players, que não estão esperando por ele
+func (b *BlockMessage) lockHigh(ctx context.Context) {
+	start := b.clock.Now(ctx)
+	contended := !b.mutex.TryLock(true)
+	if contended {
+		b.mutex.Lock(true)
+	}
+	b.clock.Sync(ctx, b.releasedAt)
+	b.lockedAt = b.clock.Now(ctx)
+	b.locks.acquired(b.lockedAt.Sub(start), contended)
+}
+
+func (b *BlockMessage) unlockHigh(ctx context.Context) {
+	b.releasedAt = b.clock.Now(ctx)
+	b.locks.released(b.releasedAt.Sub(b.lockedAt))
+	b.mutex.Unlock(true)
+}
+
+func blockMessageAt(m Matrix, x, y int) *BlockMessage {
+	return m[x][y].(*BlockMessage)
+}
+
+// Replica os locks feitos por um player em todas as outras réplicas. Cada
+// player tem a sua própria goroutine de SyncLocks: com uma goroutine só, o
+// lock pedido por um player poderia ficar esperando o unlock de outro que
+// está atrás dele na mesma fila
+func SyncLocks(lockSync <-chan [5]int, replicas []Matrix) {
+	// Vale lembrar:
+	//   	- lock[0] = playerId
+	//		- lock[1] = 0 se for operação de Lock e 1 se for operação de Unlock
+	//		- lock[2] = Coordenada x do bloco
+	//		- lock[3] = Coordenada y do bloco
+	//		- lock[4] = Instante do envio em nanossegundos, no relógio do player
+	// A goroutine tem a sua própria linha do tempo no relógio virtual
+	ctx := tools.WithTimeline(context.Background(), time.Time{})
+	for lock := range lockSync {
+		id, op, x, y := lock[0], lock[1], lock[2], lock[3]
+		// Os locks são feitos sempre na ordem das réplicas, o que evita que
+		// duas goroutines de SyncLocks fiquem esperando uma pela outra
+		for i, matrix := range replicas {
+			if i == id-1 {
+				continue
+			}
+			block := blockMessageAt(matrix, x, y)
+			block.receive(ctx, lock[4])
+			if op == 0 {
+				// Operação de lock
+				block.emitWait(id, SyncLocksGoroutine(id))
+				block.lockHigh(ctx)
+				block.emitSync(EventLockAcquired, id)
+			} else {
+				// Operação de unlock
+				block.emitSync(EventLockReleased, id)
+				block.unlockHigh(ctx)
+			}
+		}
+	}
+}
+
+func UpdateMatrix(updates <-chan [5]int, replicas []Matrix) {
+	// Vale lembrar:
+	//   	- update[0] = playerId
+	//		- update[1] = O novo valor da saúde do bloco
+	//		- update[2] = Coordenada x do bloco
+	//		- update[3] = Coordenada y do bloco
+	//		- update[4] = Instante do envio em nanossegundos, no relógio do player
+	ctx := tools.WithTimeline(context.Background(), time.Time{})
+	for update := range updates {
+		id, health, x, y := update[0], update[1], update[2], update[3]
+		for i, matrix := range replicas {
+			if i == id-1 {
+				continue
+			}
+			block := blockMessageAt(matrix, x, y)
+			block.receive(ctx, update[4])
+			block.emitWait(id, UpdateMatrixGoroutine)
+			block.lockHigh(ctx)
+			block.Health = health
+			block.last = &Update{Player: id, Health: health, Goroutine: UpdateMatrixGoroutine}
+			// Só o golpe que destrói o bloco envia saúde zero, então quem enviou é quem destruiu
+			if health == 0 && block.KilledBy == 0 {
+				block.KilledBy = id
+			}
+			if block.events != nil {
+				block.emitEvent(Event{Kind: EventUpdatePropagated, Player: id, Health: block.Health, Goroutine: UpdateMatrixGoroutine})
+			}
+			block.unlockHigh(ctx)
+		}
+		if pending := blockMessageAt(replicas[id-1], x, y).pending; pending != nil {
+			pending.Add(-1)
+		}
+	}
+}
+
+// Tabuleiro da troca de mensagens: cada player ataca a sua própria réplica e
+// as goroutines SyncLocks e UpdateMatrix mantêm as réplicas sincronizadas.
+// As mensagens passam por filas sem limite porque o player envia enquanto
+// segura o lock do seu bloco: com um canal cheio, SyncLocks ou UpdateMatrix
+// podem estar esperando justamente por esse lock e o jogo trava
+type messageBoard struct {
+	replicas []Matrix
+	lockSync []chan<- [5]int
+	updates  chan<- [5]int
+	// Atualizações pendentes de cada bloco, na ordem dos ids
+	pending []atomic.Int32
+	wg      sync.WaitGroup
+	close   sync.Once
+}
+
+func NewMessageBoard(width, height, players int, rules BlockRules, clock tools.Clock, events Sink, chaos *tools.Chaos) Board {
+	updates, updatesOut := tools.NewMailbox[[5]int]()
+	board := &messageBoard{
+		replicas: make([]Matrix, players),
+		lockSync: make([]chan<- [5]int, players),
+		updates:  updates,
+		pending:  make([]atomic.Int32, width*height),
+	}
+
+	lockSyncOut := make([]<-chan [5]int, players)
+	for i := range board.replicas {
+		lockSync, out := tools.NewMailbox[[5]int]()
+		board.lockSync[i] = lockSync
+		// Os locks só podem ser atrasados: um unlock entregue antes do lock
+		// derrubaria o programa
+		lockSyncOut[i] = tools.ChaosChannel(chaos, out, false)
+		board.replicas[i] = NewMatrix(width, height, func(id, x, y int) Block {
+			block := NewBlockMessage(id, x, y, lockSync, updates, rules, clock, events)
+			block.replica = i + 1
+			block.pending = &board.pending[id-1]
+			block.mutex = chaos.PriorityLocker(block.mutex)
+			block.chaos = chaos
+			return block
+		})
+	}
+	updatesOut = tools.ChaosChannel(chaos, updatesOut, true)
+
+	// Inicia as goroutines de sincronização e de atualização
+	for _, out := range lockSyncOut {
+		board.wg.Add(1)
+		go func(out <-chan [5]int) {
+			defer board.wg.Done()
+			SyncLocks(out, board.replicas)
+		}(out)
+	}
+	board.wg.Add(1)
+	go func() {
+		defer board.wg.Done()
+		UpdateMatrix(updatesOut, board.replicas)
+	}()
+	return board
+}
+
+func (b *messageBoard) Block(player *Player, x, y int) Block {
+	return b.replicas[player.Id-1][x][y]
+}
+
+func (b *messageBoard) Replicas() []Matrix {
+	return b.replicas
+}
+
+// Compara as réplicas bloco a bloco com o jogo em andamento. Os locks de um
+// bloco são pegos em todas as réplicas, na mesma ordem do SyncLocks, e ele só
+// é comparado se nenhuma atualização dele está a caminho
+func (b *messageBoard) CheckReplicas() []Divergence {
+	var divergences []Divergence
+	blocks := make([]*BlockMessage, len(b.replicas))
+	for i, row := range b.replicas[0] {
+		for j := range row {
+			for r, matrix := range b.replicas {
+				blocks[r] = blockMessageAt(matrix, i, j)
+				blocks[r].mutex.Lock(true)
+			}
+			if blocks[0].pending.Load() == 0 {
+				cells := make([]ReplicaCell, len(blocks))
+				for r, block := range blocks {
+					cells[r] = ReplicaCell{Replica: r + 1, Health: block.Health, Last: block.last}
+				}
+				if divergence, ok := diverge(i, j, blocks[0].Id, cells); ok {
+					divergences = append(divergences, divergence)
+				}
+			}
+			for _, block := range blocks {
+				block.mutex.Unlock(true)
+			}
+		}
+	}
+	return divergences
+}
+
+// Fecha os canais e aguarda as goroutines aplicarem as mensagens pendentes
+func (b *messageBoard) Close() {
+	b.close.Do(func() {
+		for _, lockSync := range b.lockSync {
+			close(lockSync)
+		}
+		close(b.updates)
+	})
+	b.wg.Wait()
+}
+
+var MessageStrategy = Strategy{
+	Name:  "messages",
+	Label: "TROCA DE MENSAGENS",
+	NewBoard: func(cfg BoardConfig) Board {
+		return NewMessageBoard(cfg.Width, cfg.Height, cfg.Players, cfg.Blocks, cfg.Clock, cfg.Events, cfg.Chaos)
+	},
+}
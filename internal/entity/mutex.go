@@ -0,0 +1,72 @@
+package entity
+
+import (
+	"context"
+	"sync"
+
+	"github.com/brnocorreia/concurrency/internal/tools"
+)
+
+// Implementação dos blocos para MUTEX
+type BlockMutex struct {
+	blockState
+	mutex tools.Locker
+}
+
+func NewBlockMutex(id int, mutex tools.Locker, rules BlockRules, clock tools.Clock, events Sink) *BlockMutex {
+	return &BlockMutex{
+		blockState: newBlockState(id, rules, clock, events),
+		mutex:      mutex,
+	}
+}
+
+func (b *BlockMutex) Hit(ctx context.Context, player *Player) bool {
+	b.attempt(player.Id)
+	start := b.clock.Now(ctx)
+	// O TryLock só serve para saber se o player vai precisar esperar
+	contended := !b.mutex.TryLock()
+	if contended {
+		b.mutex.Lock()
+	}
+	defer b.mutex.Unlock() // Garante que a operação de desbloqueio ocorra após o fim da interação com a memoria
+
+	b.acquired(ctx, player, player.Id, start, contended)
+	defer b.released(ctx, player, player.Id)
+	return b.hit(ctx, player)
+}
+
+func (b *BlockMutex) IsAlive() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.Health > 0
+}
+
+func (b *BlockMutex) GetHealth() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.Health
+}
+
+func (b *BlockMutex) GetKiller() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.KilledBy
+}
+
+func (b *BlockMutex) LockStats() LockStats {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.locks
+}
+
+var MutexStrategy = Strategy{
+	Name:  "mutex",
+	Label: "MUTEX",
+	NewBoard: func(cfg BoardConfig) Board {
+		return NewSharedBoard(NewMatrix(cfg.Width, cfg.Height, func(id, x, y int) Block {
+			block := NewBlockMutex(id, cfg.Chaos.Locker(&sync.Mutex{}), cfg.Blocks, cfg.Clock, cfg.Events)
+			block.chaos = cfg.Chaos
+			return block
+		}))
+	},
+}
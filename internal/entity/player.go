@@ -4,6 +4,10 @@ type Player struct {
 	Id     int
 	Power  int
 	Points int
+	// Número de ataques que o player concluiu
+	Attacks int
+	// Métricas dos locks pedidos pelo player, atualizadas só pela sua goroutine
+	locks LockStats
 }
 
 func NewPlayer(id int, power int) *Player {
@@ -26,3 +30,15 @@ func (p *Player) AddPoint() {
 	p.Points++
 	// fmt.Printf("O player %d tem %d pontos\n", p.Id, p.Points)
 }
+
+func (p *Player) GetAttacks() int {
+	return p.Attacks
+}
+
+func (p *Player) AddAttack() {
+	p.Attacks++
+}
+
+func (p *Player) GetLockStats() LockStats {
+	return p.locks
+}
@@ -0,0 +1,38 @@
+package entity
+
+import "testing"
+
+func TestPlayer(t *testing.T) {
+	tests := []struct {
+		name    string
+		power   int
+		points  int
+		attacks int
+	}{
+		{name: "novo player", power: 10},
+		{name: "com pontos", power: 25, points: 3, attacks: 7},
+		{name: "sem poder", power: 0, attacks: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			player := NewPlayer(1, tt.power)
+			for i := 0; i < tt.points; i++ {
+				player.AddPoint()
+			}
+			for i := 0; i < tt.attacks; i++ {
+				player.AddAttack()
+			}
+
+			if got := player.GetDamage(); got != tt.power {
+				t.Errorf("GetDamage() = %d, esperado %d", got, tt.power)
+			}
+			if got := player.GetPoints(); got != tt.points {
+				t.Errorf("GetPoints() = %d, esperado %d", got, tt.points)
+			}
+			if got := player.GetAttacks(); got != tt.attacks {
+				t.Errorf("GetAttacks() = %d, esperado %d", got, tt.attacks)
+			}
+		})
+	}
+}
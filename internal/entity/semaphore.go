@@ -0,0 +1,70 @@
+package entity
+
+import (
+	"context"
+
+	"github.com/brnocorreia/concurrency/internal/tools"
+)
+
+// Implementação dos blocos para SEMAPHORE
+type BlockSemaphore struct {
+	blockState
+	semaphore tools.Acquirer
+}
+
+func NewBlockSemaphore(id int, semaphore tools.Acquirer, rules BlockRules, clock tools.Clock, events Sink) *BlockSemaphore {
+	return &BlockSemaphore{
+		blockState: newBlockState(id, rules, clock, events),
+		semaphore:  semaphore,
+	}
+}
+
+func (b *BlockSemaphore) Hit(ctx context.Context, player *Player) bool {
+	b.attempt(player.Id)
+	start := b.clock.Now(ctx)
+	contended := !b.semaphore.TryAcquire()
+	if contended {
+		b.semaphore.Acquire()
+	}
+	defer b.semaphore.Release()
+
+	b.acquired(ctx, player, player.Id, start, contended)
+	defer b.released(ctx, player, player.Id)
+	return b.hit(ctx, player)
+}
+
+func (b *BlockSemaphore) IsAlive() bool {
+	b.semaphore.Acquire()
+	defer b.semaphore.Release()
+	return b.Health > 0
+}
+
+func (b *BlockSemaphore) GetHealth() int {
+	b.semaphore.Acquire()
+	defer b.semaphore.Release()
+	return b.Health
+}
+
+func (b *BlockSemaphore) GetKiller() int {
+	b.semaphore.Acquire()
+	defer b.semaphore.Release()
+	return b.KilledBy
+}
+
+func (b *BlockSemaphore) LockStats() LockStats {
+	b.semaphore.Acquire()
+	defer b.semaphore.Release()
+	return b.locks
+}
+
+var SemaphoreStrategy = Strategy{
+	Name:  "semaphore",
+	Label: "SEMAPHORE",
+	NewBoard: func(cfg BoardConfig) Board {
+		return NewSharedBoard(NewMatrix(cfg.Width, cfg.Height, func(id, x, y int) Block {
+			block := NewBlockSemaphore(id, cfg.Chaos.Acquirer(tools.NewSemaphore()), cfg.Blocks, cfg.Clock, cfg.Events)
+			block.chaos = cfg.Chaos
+			return block
+		}))
+	},
+}
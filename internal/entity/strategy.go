@@ -0,0 +1,53 @@
+package entity
+
+import "fmt"
+
+// Strategy descreve um mecanismo de sincronização do jogo. Para adicionar um
+// novo mecanismo basta implementar um Board e registrá-lo com Register
+type Strategy struct {
+	// Nome usado na flag --mode
+	Name string
+	// Nome exibido nos logs
+	Label    string
+	NewBoard func(cfg BoardConfig) Board
+}
+
+var strategies []Strategy
+
+func init() {
+	// A ordem de registro é a ordem de execução do modo "all"
+	Register(MutexStrategy)
+	Register(SemaphoreStrategy)
+	Register(MessageStrategy)
+	Register(AtomicStrategy)
+	Register(ActorStrategy)
+}
+
+func Register(strategy Strategy) {
+	if _, ok := LookupStrategy(strategy.Name); ok {
+		panic(fmt.Sprintf("estratégia %q já registrada", strategy.Name))
+	}
+	strategies = append(strategies, strategy)
+}
+
+func LookupStrategy(name string) (Strategy, bool) {
+	for _, strategy := range strategies {
+		if strategy.Name == name {
+			return strategy, true
+		}
+	}
+	return Strategy{}, false
+}
+
+// Retorna as estratégias na ordem em que foram registradas
+func Strategies() []Strategy {
+	return append([]Strategy(nil), strategies...)
+}
+
+func StrategyNames() []string {
+	names := make([]string, len(strategies))
+	for i, strategy := range strategies {
+		names[i] = strategy.Name
+	}
+	return names
+}
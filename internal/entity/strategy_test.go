@@ -0,0 +1,25 @@
+package entity
+
+import "testing"
+
+func TestLookupStrategy(t *testing.T) {
+	for _, name := range StrategyNames() {
+		strategy, ok := LookupStrategy(name)
+		if !ok || strategy.Name != name {
+			t.Errorf("LookupStrategy(%q) = %q, %v", name, strategy.Name, ok)
+		}
+	}
+
+	if _, ok := LookupStrategy("all"); ok {
+		t.Error(`"all" não deve ser uma estratégia registrada`)
+	}
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Register() aceitou uma estratégia com nome repetido")
+		}
+	}()
+	Register(MutexStrategy)
+}
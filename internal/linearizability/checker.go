@@ -0,0 +1,253 @@
+package linearizability
+
+import (
+	"cmp"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/brnocorreia/concurrency/internal/entity"
+)
+
+// Ataques de um bloco que nenhuma ordem sequencial explica. Initial é a saúde
+// de onde a sub-história parte, nil quando ela começa depois de outros
+// ataques e nenhuma saúde inicial a explica
+type Violation struct {
+	Block      int         `json:"block"`
+	Initial    *int        `json:"initial,omitempty"`
+	Operations []Operation `json:"operations"`
+}
+
+// Resultado da verificação de um histórico
+type Report struct {
+	Operations int         `json:"operations"`
+	Violations []Violation `json:"violations,omitempty"`
+}
+
+// Verifica se cada bloco se comporta como um registrador atômico: existe uma
+// ordem sequencial dos ataques, que respeita a ordem em que eles aconteceram,
+// na qual cada ataque deixa no bloco a saúde que retornou. A propriedade é
+// local, então cada bloco é verificado separadamente
+func Check(ops []Operation, rules entity.BlockRules) Report {
+	report := Report{Operations: len(ops)}
+	blocks := make(map[int][]Operation)
+	for _, op := range ops {
+		blocks[op.Block] = append(blocks[op.Block], op)
+	}
+	ids := make([]int, 0, len(blocks))
+	for id := range blocks {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		history := blocks[id]
+		slices.SortFunc(history, func(a, b Operation) int { return cmp.Compare(a.Call, b.Call) })
+		blockType := rules.Type(id)
+		initial := blockType.Health
+		if linearizable(history, blockType, &initial) {
+			continue
+		}
+		report.Violations = append(report.Violations, minimize(id, history, blockType))
+	}
+	return report
+}
+
+// Aplica o ataque na saúde do modelo sequencial e diz se o resultado bate
+// com o que o ataque retornou
+func step(health int, op Operation, blockType entity.BlockType) (int, bool) {
+	if health <= 0 {
+		return health, op.Missed
+	}
+	next := blockType.HealthAfterHit(health, op.Damage)
+	return next, !op.Missed && op.Health == next
+}
+
+// Início ou fim de um ataque na lista ordenada pelo relógio lógico
+type node struct {
+	op    int
+	call  bool
+	match *node
+	prev  *node
+	next  *node
+}
+
+// Tira o ataque da lista, com o seu início e o seu fim
+func lift(call *node) {
+	call.prev.next = call.next
+	call.next.prev = call.prev
+	ret := call.match
+	ret.prev.next = ret.next
+	if ret.next != nil {
+		ret.next.prev = ret.prev
+	}
+}
+
+// Devolve o ataque à lista, desfazendo o lift
+func unlift(call *node) {
+	ret := call.match
+	ret.prev.next = ret
+	if ret.next != nil {
+		ret.next.prev = ret
+	}
+	call.prev.next = call
+	call.next.prev = call
+}
+
+// Procura uma linearização dos ataques, ordenados por Call, partindo da saúde
+// inicial, ou de qualquer saúde até a do tipo quando initial é nil. É o
+// algoritmo de Wing e Gong com a memoização de Lowe: um estado já visitado,
+// com os mesmos ataques linearizados e a mesma saúde, não é explorado de novo
+func linearizable(ops []Operation, blockType entity.BlockType, initial *int) bool {
+	if initial == nil {
+		for health := 0; health <= blockType.Health; health++ {
+			if linearizable(ops, blockType, &health) {
+				return true
+			}
+		}
+		return false
+	}
+
+	nodes := make([]*node, 0, 2*len(ops))
+	for i := range ops {
+		call := &node{op: i, call: true}
+		ret := &node{op: i, match: call}
+		call.match = ret
+		nodes = append(nodes, call, ret)
+	}
+	time := func(n *node) uint64 {
+		if n.call {
+			return ops[n.op].Call
+		}
+		return ops[n.op].Return
+	}
+	slices.SortFunc(nodes, func(a, b *node) int { return cmp.Compare(time(a), time(b)) })
+	head := &node{}
+	prev := head
+	for _, n := range nodes {
+		prev.next = n
+		n.prev = prev
+		prev = n
+	}
+
+	type frame struct {
+		call   *node
+		health int
+	}
+	var stack []frame
+	linearized := make([]uint64, (len(ops)+63)/64)
+	visited := make(map[string]bool)
+	key := func(health int) string {
+		buf := binary.LittleEndian.AppendUint64(nil, uint64(health))
+		for _, word := range linearized {
+			buf = binary.LittleEndian.AppendUint64(buf, word)
+		}
+		return string(buf)
+	}
+
+	health := *initial
+	entry := head.next
+	for head.next != nil {
+		if entry.call {
+			if next, ok := step(health, ops[entry.op], blockType); ok {
+				linearized[entry.op/64] |= 1 << (entry.op % 64)
+				if k := key(next); !visited[k] {
+					visited[k] = true
+					stack = append(stack, frame{call: entry, health: health})
+					health = next
+					lift(entry)
+					entry = head.next
+					continue
+				}
+				linearized[entry.op/64] &^= 1 << (entry.op % 64)
+			}
+			entry = entry.next
+			continue
+		}
+
+		// O fim de um ataque que não foi linearizado: volta a última escolha
+		if len(stack) == 0 {
+			return false
+		}
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		health = top.health
+		linearized[top.call.op/64] &^= 1 << (top.call.op % 64)
+		unlift(top.call)
+		entry = top.call.next
+	}
+	return true
+}
+
+// Posições em que nenhum ataque está em andamento: todos os anteriores já
+// retornaram quando o seguinte começa. Inclui o início e o fim do histórico
+func quiescent(ops []Operation) []int {
+	cuts := []int{0}
+	var last uint64
+	for i, op := range ops {
+		if i > 0 && last < op.Call {
+			cuts = append(cuts, i)
+		}
+		last = max(last, op.Return)
+	}
+	return append(cuts, len(ops))
+}
+
+// Reduz o histórico de um bloco que não é linearizável à menor janela entre
+// dois pontos sem ataques em andamento que continua não linearizável. Os
+// ataques antes da janela terminaram antes dela começar, então uma janela sem
+// linearização a partir de nenhuma saúde é uma violação por si só
+func minimize(block int, ops []Operation, blockType entity.BlockType) Violation {
+	cuts := quiescent(ops)
+	initial := blockType.Health
+
+	// O primeiro prefixo que não é linearizável. Um prefixo de um histórico
+	// linearizável também é, então a busca pode ser binária
+	i, _ := slices.BinarySearchFunc(cuts[1:], true, func(end int, _ bool) int {
+		if linearizable(ops[:end], blockType, &initial) {
+			return -1
+		}
+		return 1
+	})
+	end := cuts[1:][min(i, len(cuts)-2)]
+
+	// O início mais tardio que ainda não tem linearização
+	for j := len(cuts) - 1; j > 0; j-- {
+		start := cuts[j]
+		if start >= end {
+			continue
+		}
+		if !linearizable(ops[start:end], blockType, nil) {
+			return Violation{Block: block, Operations: ops[start:end]}
+		}
+	}
+	return Violation{Block: block, Initial: &initial, Operations: ops[:end]}
+}
+
+func (o Operation) String() string {
+	result := fmt.Sprintf("saúde %d", o.Health)
+	if o.Missed {
+		result = "bloco já destruído"
+	}
+	return fmt.Sprintf("[%d, %d] player %d golpe de %d -> %s", o.Call, o.Return, o.Player, o.Damage, result)
+}
+
+// Imprime o resultado com os ataques de cada violação
+func (r Report) Fprint(w io.Writer) {
+	if len(r.Violations) == 0 {
+		fmt.Fprintf(w, "Os %d ataques são linearizáveis\n", r.Operations)
+		return
+	}
+	fmt.Fprintf(w, "Blocos não linearizáveis em %d ataques: %d\n", r.Operations, len(r.Violations))
+	for _, violation := range r.Violations {
+		from := "de qualquer saúde"
+		if violation.Initial != nil {
+			from = fmt.Sprintf("da saúde inicial %d", *violation.Initial)
+		}
+		fmt.Fprintf(w, "bloco %d, nenhuma ordem sequencial destes ataques parte %s:\n", violation.Block, from)
+		for _, op := range violation.Operations {
+			fmt.Fprintf(w, "  %s\n", op)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package linearizability
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/brnocorreia/concurrency/internal/entity"
+)
+
+func hit(player, block, damage int, call, ret uint64, health int) Operation {
+	return Operation{Player: player, Block: block, Damage: damage, Call: call, Return: ret, Health: health}
+}
+
+func miss(player, block, damage int, call, ret uint64) Operation {
+	return Operation{Player: player, Block: block, Damage: damage, Call: call, Return: ret, Missed: true}
+}
+
+func TestCheck(t *testing.T) {
+	rules := entity.BlockRules{Health: 100}
+	tests := []struct {
+		name       string
+		ops        []Operation
+		violations []int
+	}{
+		{
+			name: "sequencial",
+			ops: []Operation{
+				hit(1, 1, 30, 1, 2, 70),
+				hit(2, 1, 40, 3, 4, 30),
+				hit(1, 1, 30, 5, 6, 0),
+				miss(2, 1, 40, 7, 8),
+			},
+		},
+		{
+			// Os dois ataques se sobrepõem, então o do player 2 pode vir primeiro
+			name: "concorrente",
+			ops: []Operation{
+				hit(1, 1, 30, 1, 4, 50),
+				hit(2, 1, 20, 2, 3, 80),
+			},
+		},
+		{
+			// O player 2 começou depois do player 1 terminar, mas não viu o golpe
+			name: "atualização perdida",
+			ops: []Operation{
+				hit(1, 1, 30, 1, 2, 70),
+				hit(2, 1, 20, 3, 4, 80),
+				hit(1, 2, 10, 5, 6, 90),
+			},
+			violations: []int{1},
+		},
+		{
+			name: "golpe num bloco destruído",
+			ops: []Operation{
+				hit(1, 2, 100, 1, 2, 0),
+				hit(2, 2, 10, 3, 4, 90),
+			},
+			violations: []int{2},
+		},
+		{
+			name: "erro num bloco vivo",
+			ops: []Operation{
+				miss(1, 3, 10, 1, 2),
+			},
+			violations: []int{3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Check(tt.ops, rules)
+			if report.Operations != len(tt.ops) {
+				t.Errorf("Operations = %d, esperado %d", report.Operations, len(tt.ops))
+			}
+			var blocks []int
+			for _, violation := range report.Violations {
+				blocks = append(blocks, violation.Block)
+			}
+			if !reflect.DeepEqual(blocks, tt.violations) {
+				t.Errorf("blocos com violação = %v, esperado %v", blocks, tt.violations)
+			}
+		})
+	}
+}
+
+func TestCheckRegen(t *testing.T) {
+	rules := entity.BlockRules{Health: 100, Layout: []entity.BlockType{{Name: "regen", Health: 100, Regen: 5}}}
+	ops := []Operation{
+		hit(1, 1, 30, 1, 2, 75),
+		hit(2, 1, 10, 3, 4, 70),
+	}
+	if report := Check(ops, rules); report.Violations != nil {
+		t.Errorf("Check() = %+v, esperado sem violações", report.Violations)
+	}
+}
+
+func TestMinimize(t *testing.T) {
+	rules := entity.BlockRules{Health: 100}
+	ops := []Operation{
+		hit(1, 1, 10, 1, 2, 90),
+		hit(2, 1, 10, 3, 4, 80),
+		// Janela com dois ataques concorrentes que viram a mesma saúde
+		hit(1, 1, 10, 5, 7, 70),
+		hit(2, 1, 10, 6, 8, 70),
+		hit(1, 1, 10, 9, 10, 60),
+		hit(2, 1, 10, 11, 12, 50),
+	}
+
+	report := Check(ops, rules)
+	want := []Violation{{Block: 1, Operations: ops[2:4]}}
+	if !reflect.DeepEqual(report.Violations, want) {
+		t.Errorf("Violations = %+v, esperado %+v", report.Violations, want)
+	}
+
+	// Sem ataques antes, a janela parte da saúde inicial
+	ops = []Operation{
+		hit(1, 1, 10, 1, 2, 80),
+		hit(2, 1, 10, 3, 4, 70),
+	}
+	initial := 100
+	report = Check(ops, rules)
+	want = []Violation{{Block: 1, Initial: &initial, Operations: ops[:1]}}
+	if !reflect.DeepEqual(report.Violations, want) {
+		t.Errorf("Violations = %+v, esperado %+v", report.Violations, want)
+	}
+}
+
+func TestReportFprint(t *testing.T) {
+	initial := 100
+	report := Report{
+		Operations: 12,
+		Violations: []Violation{
+			{Block: 4, Initial: &initial, Operations: []Operation{hit(1, 4, 30, 1, 2, 80)}},
+			{Block: 7, Operations: []Operation{miss(2, 7, 10, 5, 6)}},
+		},
+	}
+
+	var out bytes.Buffer
+	report.Fprint(&out)
+	for _, want := range []string{
+		"Blocos não linearizáveis em 12 ataques: 2\n",
+		"bloco 4, nenhuma ordem sequencial destes ataques parte da saúde inicial 100:\n  [1, 2] player 1 golpe de 30 -> saúde 80\n",
+		"bloco 7, nenhuma ordem sequencial destes ataques parte de qualquer saúde:\n  [5, 6] player 2 golpe de 10 -> bloco já destruído\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Fprint() = %q, esperado conter %q", out.String(), want)
+		}
+	}
+
+	out.Reset()
+	Report{Operations: 3}.Fprint(&out)
+	if got := out.String(); got != "Os 3 ataques são linearizáveis\n" {
+		t.Errorf("Fprint() = %q", got)
+	}
+}
@@ -0,0 +1,71 @@
+package linearizability
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/brnocorreia/concurrency/internal/entity"
+)
+
+// Ataque de um player a um bloco, do início ao fim do Hit. Call e Return são
+// instantes de um relógio lógico compartilhado por todos os players: um
+// ataque que retornou antes de outro começar tem Return menor que o Call dele
+type Operation struct {
+	Player int    `json:"player"`
+	Block  int    `json:"block"`
+	Damage int    `json:"damage"`
+	Call   uint64 `json:"call"`
+	Return uint64 `json:"return"`
+	// Indica que o bloco já estava destruído. Caso contrário, Health é a
+	// saúde que o ataque deixou no bloco
+	Missed bool `json:"missed,omitempty"`
+	Health int  `json:"health"`
+}
+
+// Histórico dos ataques do jogo. A saúde deixada por cada ataque vem do
+// evento de hit, então o histórico também precisa receber os eventos
+type History struct {
+	clock atomic.Uint64
+	mutex sync.Mutex
+	ops   []Operation
+	// Saúde do último hit de cada player, lida quando o Hit dele retorna
+	health map[int]int
+}
+
+func NewHistory() *History {
+	return &History{health: make(map[int]int)}
+}
+
+// Marca o início de um ataque. Deve ser chamado antes do Hit
+func (h *History) Invoke(player, block, damage int) Operation {
+	return Operation{Player: player, Block: block, Damage: damage, Call: h.clock.Add(1)}
+}
+
+// Marca o fim de um ataque com o retorno do Hit
+func (h *History) Return(op Operation, hit bool) {
+	op.Return = h.clock.Add(1)
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if hit {
+		op.Health = h.health[op.Player]
+	} else {
+		op.Missed = true
+	}
+	h.ops = append(h.ops, op)
+}
+
+func (h *History) Emit(event entity.Event) {
+	if event.Kind != entity.EventHit {
+		return
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.health[event.Player] = event.Health
+}
+
+// Ataques concluídos, na ordem em que retornaram
+func (h *History) Operations() []Operation {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return append([]Operation(nil), h.ops...)
+}
@@ -0,0 +1,27 @@
+package linearizability
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/brnocorreia/concurrency/internal/entity"
+)
+
+func TestHistory(t *testing.T) {
+	history := NewHistory()
+
+	first := history.Invoke(1, 3, 30)
+	second := history.Invoke(2, 3, 20)
+	history.Emit(entity.Event{Kind: entity.EventAttempt, Player: 1, Block: 3, Health: 100})
+	history.Emit(entity.Event{Kind: entity.EventHit, Player: 1, Block: 3, Health: 70})
+	history.Return(first, true)
+	history.Return(second, false)
+
+	want := []Operation{
+		{Player: 1, Block: 3, Damage: 30, Call: 1, Return: 3, Health: 70},
+		{Player: 2, Block: 3, Damage: 20, Call: 2, Return: 4, Missed: true},
+	}
+	if got := history.Operations(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Operations() = %+v, esperado %+v", got, want)
+	}
+}
@@ -0,0 +1,193 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/brnocorreia/concurrency/internal/entity"
+)
+
+// Content-Type do formato de texto do Prometheus
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Ataque esperando pelo lock, identificado pelo player, bloco e réplica
+type waitKey struct {
+	player  int
+	block   int
+	replica int
+}
+
+type histogram struct {
+	counts [len(entity.WaitBuckets) + 1]uint64
+	sum    time.Duration
+	count  uint64
+}
+
+func (h *histogram) observe(d time.Duration) {
+	bucket := len(entity.WaitBuckets)
+	for i, limit := range entity.WaitBuckets {
+		if d <= limit {
+			bucket = i
+			break
+		}
+	}
+	h.counts[bucket]++
+	h.sum += d
+	h.count++
+}
+
+// Métricas acumuladas de uma estratégia, somando todas as suas execuções
+type modeMetrics struct {
+	events map[entity.EventKind]uint64
+	wait   histogram
+	// Quando cada ataque ainda sem lock começou a esperar
+	waiting map[waitKey]time.Time
+}
+
+// Registry acumula as métricas do jogo a partir dos eventos dos blocos e as
+// expõe no formato de texto do Prometheus. A espera pelo lock é medida no
+// relógio de parede, entre o attempt e o lock-acquired do mesmo player
+type Registry struct {
+	mutex sync.Mutex
+	modes map[string]*modeMetrics
+}
+
+func NewRegistry() *Registry {
+	return &Registry{modes: make(map[string]*modeMetrics)}
+}
+
+// Retorna o Sink que registra os eventos de uma estratégia
+func (r *Registry) Sink(mode string) entity.Sink {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.modes[mode] == nil {
+		r.modes[mode] = &modeMetrics{
+			events:  make(map[entity.EventKind]uint64),
+			waiting: make(map[waitKey]time.Time),
+		}
+	}
+	return modeSink{registry: r, mode: mode}
+}
+
+type modeSink struct {
+	registry *Registry
+	mode     string
+}
+
+func (s modeSink) Emit(event entity.Event) {
+	now := time.Now()
+	r := s.registry
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	m := r.modes[s.mode]
+	m.events[event.Kind]++
+
+	key := waitKey{player: event.Player, block: event.Block, replica: event.Replica}
+	switch event.Kind {
+	case entity.EventAttempt:
+		m.waiting[key] = now
+	case entity.EventLockAcquired:
+		// Os locks feitos pelo SyncLocks em outras réplicas não têm attempt
+		if start, ok := m.waiting[key]; ok {
+			m.wait.observe(now.Sub(start))
+			delete(m.waiting, key)
+		}
+	case entity.EventHit, entity.EventKill:
+		// Sem lock, o ataque do modo atômico termina sem lock-acquired
+		delete(m.waiting, key)
+	}
+}
+
+// Escreve todas as métricas no formato de texto do Prometheus
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	out := &countingWriter{w: bufio.NewWriter(w)}
+	modes := make([]string, 0, len(r.modes))
+	for mode := range r.modes {
+		modes = append(modes, mode)
+	}
+	slices.Sort(modes)
+
+	counter := func(name, help string, kind entity.EventKind) {
+		header(out, name, help, "counter")
+		for _, mode := range modes {
+			fmt.Fprintf(out, "%s{mode=%q} %d\n", name, mode, r.modes[mode].events[kind])
+		}
+	}
+	counter("concurrency_hits_total", "Hits that took health from a block.", entity.EventHit)
+	counter("concurrency_kills_total", "Hits that destroyed a block.", entity.EventKill)
+
+	header(out, "concurrency_events_total", "Block events emitted, by kind. Use rate() for events per second.", "counter")
+	for _, mode := range modes {
+		m := r.modes[mode]
+		kinds := make([]string, 0, len(m.events))
+		for kind := range m.events {
+			kinds = append(kinds, string(kind))
+		}
+		slices.Sort(kinds)
+		for _, kind := range kinds {
+			fmt.Fprintf(out, "concurrency_events_total{mode=%q,kind=%q} %d\n", mode, kind, m.events[entity.EventKind(kind)])
+		}
+	}
+
+	header(out, "concurrency_lock_wait_seconds", "Time players waited for a block lock, on the wall clock.", "histogram")
+	for _, mode := range modes {
+		h := r.modes[mode].wait
+		var cumulative uint64
+		for i, limit := range entity.WaitBuckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(out, "concurrency_lock_wait_seconds_bucket{mode=%q,le=%q} %d\n", mode, formatFloat(limit.Seconds()), cumulative)
+		}
+		fmt.Fprintf(out, "concurrency_lock_wait_seconds_bucket{mode=%q,le=\"+Inf\"} %d\n", mode, h.count)
+		fmt.Fprintf(out, "concurrency_lock_wait_seconds_sum{mode=%q} %s\n", mode, formatFloat(h.sum.Seconds()))
+		fmt.Fprintf(out, "concurrency_lock_wait_seconds_count{mode=%q} %d\n", mode, h.count)
+	}
+
+	header(out, "go_goroutines", "Number of goroutines that currently exist.", "gauge")
+	fmt.Fprintf(out, "go_goroutines %d\n", runtime.NumGoroutine())
+
+	if err := out.w.Flush(); err != nil {
+		return out.n, err
+	}
+	return out.n, out.err
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	r.WriteTo(w)
+}
+
+func header(w io.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// Conta os bytes escritos e guarda o primeiro erro
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
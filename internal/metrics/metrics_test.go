@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brnocorreia/concurrency/internal/entity"
+	"github.com/brnocorreia/concurrency/internal/runner"
+	"github.com/brnocorreia/concurrency/internal/tools"
+)
+
+// Lê as amostras do formato de texto, indexadas pelo nome com os labels
+func parseSamples(t *testing.T, data []byte) map[string]float64 {
+	t.Helper()
+	samples := make(map[string]float64)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndexByte(line, ' ')
+		if i < 0 {
+			t.Fatalf("linha inválida %q", line)
+		}
+		value, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			t.Fatalf("valor inválido na linha %q: %v", line, err)
+		}
+		samples[line[:i]] = value
+	}
+	return samples
+}
+
+func TestRegistryGame(t *testing.T) {
+	registry := NewRegistry()
+	game := runner.NewRunner(50, 3, 30, 3, tools.NewVirtualClock(time.Unix(0, 0)))
+	game.SetSequences(tools.NewSequences(tools.NewShape(3, 3), 50, 3, 4, tools.Uniform))
+	game.SetOutput(io.Discard)
+
+	results := make(map[string]runner.RunResult)
+	for _, strategy := range entity.Strategies() {
+		game.SetEvents(registry.Sink(strategy.Name))
+		results[strategy.Name] = game.Run(context.Background(), strategy)
+	}
+
+	var out bytes.Buffer
+	if _, err := registry.WriteTo(&out); err != nil {
+		t.Fatal(err)
+	}
+	samples := parseSamples(t, out.Bytes())
+
+	for mode, result := range results {
+		if got := samples[`concurrency_events_total{mode="`+mode+`",kind="attempt"}`]; got != 150 {
+			t.Errorf("%s: %v attempts, esperado 150", mode, got)
+		}
+		if mode == entity.MessageStrategy.Name {
+			continue
+		}
+		points := 0
+		for _, player := range result.Players {
+			points += player.Points
+		}
+		if got := samples[`concurrency_kills_total{mode="`+mode+`"}`]; got != float64(points) {
+			t.Errorf("%s: %v kills, esperado %d", mode, got, points)
+		}
+
+		// Cada ataque dos modos com lock espera uma vez
+		count := samples[`concurrency_lock_wait_seconds_count{mode="`+mode+`"}`]
+		if inf := samples[`concurrency_lock_wait_seconds_bucket{mode="`+mode+`",le="+Inf"}`]; inf != count {
+			t.Errorf("%s: bucket +Inf com %v e count %v", mode, inf, count)
+		}
+		if mode != entity.AtomicStrategy.Name && count != 150 {
+			t.Errorf("%s: %v esperas, esperado 150", mode, count)
+		}
+	}
+	if samples["go_goroutines"] < 1 {
+		t.Error("go_goroutines ausente")
+	}
+}
+
+func TestRegistryHandler(t *testing.T) {
+	registry := NewRegistry()
+	sink := registry.Sink("mutex")
+	sink.Emit(entity.Event{Kind: entity.EventAttempt, Player: 1, Block: 2, Health: entity.UnknownHealth})
+	sink.Emit(entity.Event{Kind: entity.EventLockAcquired, Player: 1, Block: 2, Health: 100})
+
+	rec := httptest.NewRecorder()
+	registry.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if got := rec.Header().Get("Content-Type"); got != ContentType {
+		t.Errorf("Content-Type = %q", got)
+	}
+
+	samples := parseSamples(t, rec.Body.Bytes())
+	tests := []struct {
+		sample string
+		want   float64
+	}{
+		{sample: `concurrency_hits_total{mode="mutex"}`, want: 0},
+		{sample: `concurrency_events_total{mode="mutex",kind="attempt"}`, want: 1},
+		{sample: `concurrency_lock_wait_seconds_bucket{mode="mutex",le="1"}`, want: 1},
+		{sample: `concurrency_lock_wait_seconds_count{mode="mutex"}`, want: 1},
+	}
+	for _, tt := range tests {
+		if got, ok := samples[tt.sample]; !ok || got != tt.want {
+			t.Errorf("%s = %v, esperado %v", tt.sample, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/brnocorreia/concurrency/internal/entity"
+	"github.com/brnocorreia/concurrency/internal/runner"
+)
+
+// Versão do formato das gravações
+const RecordingVersion = 1
+
+// Linha de uma gravação. O arquivo começa com um "header", e cada estratégia
+// gravada é um "run" seguido dos seus eventos e do seu "result". O cabeçalho
+// guarda também a versão do results.json em que a configuração foi escrita,
+// ausente nas gravações da versão 1
+type line struct {
+	Type           string         `json:"type"`
+	Version        int            `json:"version,omitempty"`
+	ResultsVersion int            `json:"results_version,omitempty"`
+	Config         *runner.Config `json:"config,omitempty"`
+	Mode           string         `json:"mode,omitempty"`
+	*Step
+	Result *runner.RunResult `json:"result,omitempty"`
+}
+
+// Evento que alterou o estado de um bloco. T é o tempo desde o início da
+// execução, lido do relógio monotônico
+type Step struct {
+	T time.Duration `json:"t_ns"`
+	entity.Event
+}
+
+// Execução gravada de uma estratégia
+type Run struct {
+	Mode   string
+	Steps  []Step
+	Result runner.RunResult
+}
+
+type Recording struct {
+	Version int
+	Config  runner.Config
+	Runs    []Run
+}
+
+// Recorder é um Sink que guarda, na ordem em que foram aplicados, os eventos
+// que alteram a saúde dos blocos. Os eventos são emitidos com o lock do
+// bloco, então a ordem gravada de cada bloco é a ordem real
+type Recorder struct {
+	mutex sync.Mutex
+	runs  []Run
+	start time.Time
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Começa a gravar a execução de uma estratégia
+func (r *Recorder) Start(mode string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.runs = append(r.runs, Run{Mode: mode})
+	r.start = time.Now()
+}
+
+// Termina a gravação da execução atual com o resultado do runner
+func (r *Recorder) Finish(result runner.RunResult) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.runs[len(r.runs)-1].Result = result
+}
+
+func (r *Recorder) Emit(event entity.Event) {
+	if event.Kind != entity.EventHit && event.Kind != entity.EventUpdatePropagated {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if len(r.runs) == 0 {
+		return
+	}
+	current := &r.runs[len(r.runs)-1]
+	current.Steps = append(current.Steps, Step{T: time.Since(r.start), Event: event})
+}
+
+// Salva a gravação com a configuração do runner
+func (r *Recorder) Save(config runner.Config, filename string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	out := bufio.NewWriter(file)
+	encoder := json.NewEncoder(out)
+	if err := encoder.Encode(line{Type: "header", Version: RecordingVersion, ResultsVersion: runner.ResultsVersion, Config: &config}); err != nil {
+		return err
+	}
+	for _, run := range r.runs {
+		if err := encoder.Encode(line{Type: "run", Mode: run.Mode}); err != nil {
+			return err
+		}
+		for i := range run.Steps {
+			if err := encoder.Encode(line{Type: "event", Step: &run.Steps[i]}); err != nil {
+				return err
+			}
+		}
+		if err := encoder.Encode(line{Type: "result", Result: &run.Result}); err != nil {
+			return err
+		}
+	}
+	if err := out.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Lê uma gravação salva pelo Recorder
+func Load(filename string) (Recording, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return Recording{}, err
+	}
+	defer file.Close()
+
+	var recording Recording
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 64<<20)
+	for number := 1; scanner.Scan(); number++ {
+		var l line
+		if err := json.Unmarshal(scanner.Bytes(), &l); err != nil {
+			return Recording{}, fmt.Errorf("%s: linha %d: %w", filename, number, err)
+		}
+
+		if number == 1 && l.Type != "header" {
+			return Recording{}, fmt.Errorf("%s: o arquivo não começa com o cabeçalho da gravação", filename)
+		}
+		var current *Run
+		if len(recording.Runs) > 0 {
+			current = &recording.Runs[len(recording.Runs)-1]
+		}
+
+		switch {
+		case l.Type == "header" && l.Config != nil:
+			if l.Version > RecordingVersion {
+				return Recording{}, fmt.Errorf("%s: versão %d da gravação não suportada", filename, l.Version)
+			}
+			version := max(l.ResultsVersion, runner.MinResultsVersion)
+			config, err := runner.UpgradeConfig(*l.Config, version)
+			if err != nil {
+				return Recording{}, fmt.Errorf("%s: %w", filename, err)
+			}
+			recording.Version = l.Version
+			recording.Config = config
+		case l.Type == "run":
+			recording.Runs = append(recording.Runs, Run{Mode: l.Mode})
+		case l.Type == "event" && l.Step != nil && current != nil:
+			current.Steps = append(current.Steps, *l.Step)
+		case l.Type == "result" && l.Result != nil && current != nil:
+			current.Result = *l.Result
+		default:
+			return Recording{}, fmt.Errorf("%s: linha %d: linha inesperada do tipo %q", filename, number, l.Type)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Recording{}, err
+	}
+	if recording.Version == 0 {
+		return Recording{}, errors.New(filename + ": gravação vazia")
+	}
+	return recording, nil
+}
@@ -0,0 +1,182 @@
+package replay
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/brnocorreia/concurrency/internal/entity"
+	"github.com/brnocorreia/concurrency/internal/runner"
+)
+
+// Estado do tabuleiro reconstruído a partir dos eventos gravados
+type State struct {
+	Width  int
+	Height int
+	// Poder de cada player, na ordem dos ids
+	Powers []int
+	// Tipo de cada bloco, na ordem dos ids
+	Types   []entity.BlockType
+	Health  [][]int
+	Killers [][]int
+	Points  []int
+}
+
+// Cria o estado inicial de uma execução, com uma matriz por réplica. O poder
+// de cada player vem do resultado gravado
+func NewState(config runner.Config, run Run) *State {
+	width, height := config.Dimensions()
+	size := width * height
+	replicas := max(len(run.Result.Replicas), 1)
+	state := &State{
+		Width:   width,
+		Height:  height,
+		Powers:  make([]int, config.NumPlayers),
+		Types:   make([]entity.BlockType, size),
+		Health:  make([][]int, replicas),
+		Killers: make([][]int, replicas),
+		Points:  make([]int, config.NumPlayers),
+	}
+	for i := range state.Powers {
+		state.Powers[i] = config.PlayerPower
+	}
+	for _, player := range run.Result.Players {
+		if player.Id >= 1 && player.Id <= len(state.Powers) {
+			state.Powers[player.Id-1] = player.Power
+		}
+	}
+
+	// Gravações antigas não guardam as regras dos blocos, que ficam com os padrões
+	rules := config.BlockRules()
+	for i := range state.Types {
+		state.Types[i] = rules.Type(i + 1)
+	}
+	for i := range state.Health {
+		state.Health[i] = make([]int, size)
+		state.Killers[i] = make([]int, size)
+		for j := range state.Health[i] {
+			state.Health[i][j] = state.Types[j].Health
+		}
+	}
+	return state
+}
+
+// Aplica um evento gravado ao estado e retorna a saúde anterior do bloco.
+// Um erro indica que a saúde gravada não é a que o golpe deixaria no bloco
+func (s *State) Apply(event entity.Event) (int, error) {
+	replica, block := max(event.Replica-1, 0), event.Block-1
+	if replica >= len(s.Health) || block < 0 || block >= len(s.Health[replica]) {
+		return 0, fmt.Errorf("bloco %d da réplica %d fora do tabuleiro", event.Block, event.Replica)
+	}
+	if event.Player < 1 || event.Player > len(s.Points) {
+		return 0, fmt.Errorf("player %d desconhecido", event.Player)
+	}
+
+	health := &s.Health[replica][block]
+	before := *health
+	switch event.Kind {
+	case entity.EventHit:
+		*health = s.Types[block].HealthAfterHit(*health, s.Powers[event.Player-1])
+		if *health == 0 {
+			s.Killers[replica][block] = event.Player
+			s.Points[event.Player-1]++
+		}
+		if *health != event.Health {
+			return before, fmt.Errorf("player %d deixou o bloco %d com %d, mas foram gravados %d", event.Player, event.Block, *health, event.Health)
+		}
+	case entity.EventUpdatePropagated:
+		// Mesma regra do UpdateMatrix: a saúde é copiada e quem envia zero destruiu o bloco
+		*health = event.Health
+		if *health == 0 && s.Killers[replica][block] == 0 {
+			s.Killers[replica][block] = event.Player
+		}
+	default:
+		return before, fmt.Errorf("evento %q não altera o bloco", event.Kind)
+	}
+	return before, nil
+}
+
+// Passos de uma execução na ordem em que devem ser reaplicados. No modo
+// atômico o evento é emitido depois do CompareAndSwap, sem lock, então dois
+// golpes no mesmo bloco podem ser gravados fora de ordem. O número do CAS
+// gravado em cada evento restaura a ordem real. Gravações antigas, sem o
+// número, são ordenadas pela saúde, o que só vale sem a recuperação dos blocos
+func Steps(run Run) []Step {
+	steps := slices.Clone(run.Steps)
+	if run.Mode != entity.AtomicStrategy.Name {
+		return steps
+	}
+
+	positions := make(map[int][]int)
+	for i, step := range steps {
+		positions[step.Block] = append(positions[step.Block], i)
+	}
+	for _, indexes := range positions {
+		ordered := make([]Step, len(indexes))
+		for i, index := range indexes {
+			ordered[i] = steps[index]
+		}
+		slices.SortStableFunc(ordered, func(a, b Step) int {
+			return cmp.Or(cmp.Compare(a.CAS, b.CAS), cmp.Compare(b.Health, a.Health))
+		})
+		for i, index := range indexes {
+			steps[index] = ordered[i]
+		}
+	}
+	return steps
+}
+
+// Compara o estado reconstruído com o resultado gravado e retorna as diferenças
+func Verify(state *State, result runner.RunResult) []string {
+	var diffs []string
+	if len(result.Replicas) != len(state.Health) {
+		diffs = append(diffs, fmt.Sprintf("%d réplicas gravadas, %d reconstruídas", len(result.Replicas), len(state.Health)))
+	}
+
+	for r := range min(len(result.Replicas), len(state.Health)) {
+		recorded := result.Replicas[r]
+		for y := range state.Height {
+			for x := range state.Width {
+				block := y*state.Width + x
+				if y >= len(recorded.Health) || x >= len(recorded.Health[y]) {
+					diffs = append(diffs, fmt.Sprintf("réplica %d: bloco %d ausente do resultado", r+1, block+1))
+					continue
+				}
+				if got, want := state.Health[r][block], recorded.Health[y][x]; got != want {
+					diffs = append(diffs, fmt.Sprintf("réplica %d: bloco %d com saúde %d, gravado %d", r+1, block+1, got, want))
+				}
+				if got, want := state.Killers[r][block], recorded.Kills[y][x]; got != want {
+					diffs = append(diffs, fmt.Sprintf("réplica %d: bloco %d destruído por %d, gravado %d", r+1, block+1, got, want))
+				}
+			}
+		}
+	}
+
+	for _, player := range result.Players {
+		if player.Id < 1 || player.Id > len(state.Points) {
+			diffs = append(diffs, fmt.Sprintf("player %d desconhecido", player.Id))
+			continue
+		}
+		if got := state.Points[player.Id-1]; got != player.Points {
+			diffs = append(diffs, fmt.Sprintf("player %d com %d pontos, gravado %d", player.Id, got, player.Points))
+		}
+	}
+	return diffs
+}
+
+// Reaplica uma execução gravada em um tabuleiro novo, chamando visit depois
+// de cada passo, e retorna o estado final e as diferenças para o resultado.
+// A reprodução para no primeiro golpe que não bate com a gravação
+func Replay(recording Recording, run Run, visit func(step Step, before int, state *State)) (*State, []string) {
+	state := NewState(recording.Config, run)
+	for i, step := range Steps(run) {
+		before, err := state.Apply(step.Event)
+		if err != nil {
+			return state, []string{fmt.Sprintf("passo %d: %v", i+1, err)}
+		}
+		if visit != nil {
+			visit(step, before, state)
+		}
+	}
+	return state, Verify(state, run.Result)
+}
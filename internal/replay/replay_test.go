@@ -0,0 +1,176 @@
+package replay
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/brnocorreia/concurrency/internal/entity"
+	"github.com/brnocorreia/concurrency/internal/runner"
+	"github.com/brnocorreia/concurrency/internal/tools"
+)
+
+// Grava uma partida de cada estratégia e lê a gravação de volta do disco
+func recordGame(t *testing.T, rules entity.BlockRules) Recording {
+	t.Helper()
+	recorder := NewRecorder()
+
+	game := runner.NewRunner(40, 3, 30, 3, tools.NewVirtualClock(time.Unix(0, 0)))
+	game.SetSequences(tools.NewSequences(tools.NewShape(3, 3), 40, 3, 11, tools.Uniform))
+	game.SetOutput(io.Discard)
+	game.SetEvents(recorder)
+	game.SetBlockRules(rules)
+
+	var runs []runner.RunResult
+	for _, strategy := range entity.Strategies() {
+		recorder.Start(strategy.Name)
+		result := game.Run(context.Background(), strategy)
+		recorder.Finish(result)
+		runs = append(runs, result)
+	}
+
+	filename := filepath.Join(t.TempDir(), "game.rec")
+	if err := recorder.Save(game.Results(runs).Config, filename); err != nil {
+		t.Fatal(err)
+	}
+	recording, err := Load(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recording.Runs) != len(runs) {
+		t.Fatalf("%d execuções lidas, esperado %d", len(recording.Runs), len(runs))
+	}
+	return recording
+}
+
+func TestReplay(t *testing.T) {
+	// Blocos que se recuperam mudam a saúde deixada por cada golpe
+	mix, err := entity.ParseBlockMix("standard,fast,armored,regen", entity.BlockTypes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mixed := entity.BlockRules{Layout: entity.GenerateBlockLayout(3, 3, mix, 5)}
+
+	for name, rules := range map[string]entity.BlockRules{"padrão": entity.DefaultBlockRules, "tipos": mixed} {
+		recording := recordGame(t, rules)
+		for _, run := range recording.Runs {
+			t.Run(name+"/"+run.Mode, func(t *testing.T) {
+				if len(run.Steps) == 0 {
+					t.Fatal("nenhum golpe gravado")
+				}
+				visited := 0
+				_, diffs := Replay(recording, run, func(Step, int, *State) { visited++ })
+				if len(diffs) > 0 {
+					t.Fatalf("a reprodução não bate com o resultado: %v", diffs)
+				}
+				if visited != len(run.Steps) {
+					t.Errorf("%d passos visitados, esperado %d", visited, len(run.Steps))
+				}
+			})
+		}
+	}
+}
+
+func TestReplayMismatch(t *testing.T) {
+	recording := recordGame(t, entity.DefaultBlockRules)
+	run := recording.Runs[0]
+
+	tests := []struct {
+		name   string
+		change func(run *Run)
+	}{
+		{name: "golpe removido", change: func(run *Run) { run.Steps = run.Steps[1:] }},
+		{name: "saúde adulterada", change: func(run *Run) { run.Steps[0].Health++ }},
+		{name: "pontos adulterados", change: func(run *Run) { run.Result.Players[0].Points++ }},
+		{name: "player desconhecido", change: func(run *Run) { run.Steps[0].Player = 99 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed := run
+			changed.Steps = append([]Step(nil), run.Steps...)
+			changed.Result.Players = append([]runner.PlayerResult(nil), run.Result.Players...)
+			tt.change(&changed)
+			if _, diffs := Replay(recording, changed, nil); len(diffs) == 0 {
+				t.Error("a gravação alterada foi aceita")
+			}
+		})
+	}
+}
+
+// Gravações antigas trazem a configuração na versão 1 dos resultados, só
+// com o lado da matriz
+func TestLoadConfigVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		wantErr bool
+	}{
+		{name: "versão 1", header: `{"type":"header","version":1,"config":{"matrix_size":3}}`},
+		{name: "versão 2", header: `{"type":"header","version":1,"results_version":2,"config":{"matrix_size":3,"width":3,"height":3}}`},
+		{name: "versão futura", header: `{"type":"header","version":1,"results_version":3,"config":{"width":3,"height":3}}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "game.rec")
+			if err := os.WriteFile(filename, []byte(tt.header+"\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			recording, err := Load(filename)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() erro = %v, esperado erro: %v", err, tt.wantErr)
+			}
+			if width, height := recording.Config.Dimensions(); !tt.wantErr && (width != 3 || height != 3) {
+				t.Errorf("Dimensions() = %dx%d, esperado 3x3", width, height)
+			}
+		})
+	}
+}
+
+// No modo atômico os golpes de um bloco podem ser gravados fora de ordem
+func TestStepsAtomicOrder(t *testing.T) {
+	run := Run{
+		Mode: entity.AtomicStrategy.Name,
+		Steps: []Step{
+			{Event: entity.Event{Kind: entity.EventHit, Player: 1, Block: 1, Health: 40}},
+			{Event: entity.Event{Kind: entity.EventHit, Player: 2, Block: 2, Health: 70}},
+			{Event: entity.Event{Kind: entity.EventHit, Player: 2, Block: 1, Health: 70}},
+		},
+	}
+	steps := Steps(run)
+	if steps[0].Health != 70 || steps[0].Block != 1 || steps[1].Block != 2 || steps[2].Health != 40 {
+		t.Errorf("passos fora de ordem: %+v", steps)
+	}
+	if run.Steps[0].Health != 40 {
+		t.Error("Steps alterou a gravação")
+	}
+}
+
+// Com a recuperação, um golpe posterior pode deixar mais saúde no bloco, e
+// só o número do CAS ordena os eventos
+func TestStepsAtomicCAS(t *testing.T) {
+	run := Run{
+		Mode: entity.AtomicStrategy.Name,
+		Steps: []Step{
+			{Event: entity.Event{Kind: entity.EventHit, Player: 1, Block: 1, Health: 80, CAS: 2}},
+			{Event: entity.Event{Kind: entity.EventHit, Player: 2, Block: 1, Health: 0, CAS: 3}},
+			{Event: entity.Event{Kind: entity.EventKill, Player: 2, Block: 1, Health: 0, CAS: 3}},
+			{Event: entity.Event{Kind: entity.EventHit, Player: 2, Block: 1, Health: 75, CAS: 1}},
+		},
+	}
+	var got []int
+	for _, step := range Steps(run) {
+		got = append(got, step.CAS)
+	}
+	if want := []int{1, 2, 3, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("passos na ordem %v, esperado %v", got, want)
+	}
+	if steps := Steps(run); steps[2].Kind != entity.EventHit || steps[3].Kind != entity.EventKill {
+		t.Errorf("o golpe e a morte do mesmo CAS trocaram de ordem: %+v", steps[2:])
+	}
+}
@@ -0,0 +1,204 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/brnocorreia/concurrency/internal/entity"
+	"github.com/brnocorreia/concurrency/internal/tools"
+)
+
+// Versão do formato do arquivo de benchmark
+const BenchVersion = 1
+
+// Combinação de parâmetros de um jogo do benchmark
+type BenchPoint struct {
+	MatrixSize  int `json:"matrix_size"`
+	NumAttacks  int `json:"num_attacks"`
+	NumPlayers  int `json:"num_players"`
+	PlayerPower int `json:"player_power"`
+}
+
+// Grade de parâmetros do benchmark. Todas as combinações são executadas
+type BenchGrid struct {
+	MatrixSizes  []int
+	NumAttacks   []int
+	NumPlayers   []int
+	PlayerPowers []int
+}
+
+func (g BenchGrid) Points() []BenchPoint {
+	var points []BenchPoint
+	for _, size := range g.MatrixSizes {
+		for _, attacks := range g.NumAttacks {
+			for _, players := range g.NumPlayers {
+				for _, power := range g.PlayerPowers {
+					points = append(points, BenchPoint{
+						MatrixSize:  size,
+						NumAttacks:  attacks,
+						NumPlayers:  players,
+						PlayerPower: power,
+					})
+				}
+			}
+		}
+	}
+	return points
+}
+
+// Configuração do benchmark
+type Bench struct {
+	Grid       BenchGrid
+	Strategies []entity.Strategy
+	// Número de execuções de cada estratégia em cada ponto da grade
+	Runs  int
+	Seed  int64
+	Clock tools.Clock
+	// Distribuição dos alvos das sequências, uniforme quando zerada
+	Distribution tools.Distribution
+}
+
+// Estatísticas das execuções de uma estratégia num ponto da grade. As
+// durações são medidas no relógio de parede: com o relógio virtual os
+// ataques não dormem e o que sobra é o custo da sincronização
+type BenchStats struct {
+	Mode string `json:"mode"`
+	BenchPoint
+	Runs   int           `json:"runs"`
+	Mean   time.Duration `json:"mean_ns"`
+	StdDev time.Duration `json:"stddev_ns"`
+	P50    time.Duration `json:"p50_ns"`
+	P95    time.Duration `json:"p95_ns"`
+	P99    time.Duration `json:"p99_ns"`
+	// Ataques concluídos por segundo, somando todas as execuções
+	Throughput float64 `json:"throughput"`
+}
+
+// Documento salvo ao final do benchmark. Interrupted indica que o benchmark
+// foi cancelado e que as estatísticas cobrem apenas as execuções concluídas
+type BenchResults struct {
+	Version      int          `json:"version"`
+	GeneratedAt  time.Time    `json:"generated_at"`
+	Runs         int          `json:"runs"`
+	Seed         int64        `json:"seed"`
+	Distribution string       `json:"distribution"`
+	VirtualClock bool         `json:"virtual_clock"`
+	Interrupted  bool         `json:"interrupted"`
+	Stats        []BenchStats `json:"stats"`
+}
+
+// Executa o benchmark. A execução k de todas as estratégias usa as mesmas
+// sequências, geradas com Seed+k, e as estratégias são intercaladas para que
+// variações da máquina afetem todas da mesma forma
+func RunBench(ctx context.Context, bench Bench) BenchResults {
+	_, virtual := bench.Clock.(*tools.VirtualClock)
+	results := BenchResults{
+		Version:      BenchVersion,
+		GeneratedAt:  time.Now(),
+		Runs:         bench.Runs,
+		Seed:         bench.Seed,
+		Distribution: bench.Distribution.String(),
+		VirtualClock: virtual,
+	}
+
+	for _, point := range bench.Grid.Points() {
+		durations := make([][]time.Duration, len(bench.Strategies))
+		attacks := make([]int, len(bench.Strategies))
+
+	runs:
+		for k := 0; k < bench.Runs; k++ {
+			sequences := tools.NewSequences(tools.NewShape(point.MatrixSize, point.MatrixSize), point.NumAttacks, point.NumPlayers, bench.Seed+int64(k), bench.Distribution)
+			for i, strategy := range bench.Strategies {
+				game := NewRunner(point.NumAttacks, point.MatrixSize, point.PlayerPower, point.NumPlayers, bench.Clock)
+				game.SetSequences(sequences)
+				game.SetOutput(io.Discard)
+
+				start := time.Now()
+				result := game.Run(ctx, strategy)
+				elapsed := time.Since(start)
+				// Uma execução interrompida não entra nas estatísticas
+				if result.Interrupted {
+					results.Interrupted = true
+					break runs
+				}
+
+				durations[i] = append(durations[i], elapsed)
+				for _, player := range result.Players {
+					attacks[i] += player.Attacks
+				}
+			}
+		}
+
+		for i, strategy := range bench.Strategies {
+			if len(durations[i]) == 0 {
+				continue
+			}
+			stats := Summarize(durations[i], attacks[i])
+			stats.Mode = strategy.Name
+			stats.BenchPoint = point
+			results.Stats = append(results.Stats, stats)
+		}
+		if results.Interrupted {
+			break
+		}
+	}
+	return results
+}
+
+// Calcula as estatísticas de um conjunto de durações. attacks é o total de
+// ataques concluídos em todas as execuções
+func Summarize(durations []time.Duration, attacks int) BenchStats {
+	stats := BenchStats{Runs: len(durations)}
+	if len(durations) == 0 {
+		return stats
+	}
+
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	mean := float64(total) / float64(len(sorted))
+
+	// Desvio padrão amostral, zero com uma única execução
+	if len(sorted) > 1 {
+		var sum float64
+		for _, d := range sorted {
+			sum += (float64(d) - mean) * (float64(d) - mean)
+		}
+		stats.StdDev = time.Duration(math.Sqrt(sum / float64(len(sorted)-1)))
+	}
+
+	stats.Mean = time.Duration(mean)
+	stats.P50 = percentile(sorted, 50)
+	stats.P95 = percentile(sorted, 95)
+	stats.P99 = percentile(sorted, 99)
+	if total > 0 {
+		stats.Throughput = float64(attacks) / total.Seconds()
+	}
+	return stats
+}
+
+// Percentil pelo método do posto mais próximo. sorted deve estar ordenado
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func SaveBenchResults(results BenchResults, filename string) error {
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
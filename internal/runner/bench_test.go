@@ -0,0 +1,146 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/brnocorreia/concurrency/internal/entity"
+	"github.com/brnocorreia/concurrency/internal/tools"
+)
+
+func TestSummarize(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name      string
+		durations []time.Duration
+		attacks   int
+		want      BenchStats
+	}{
+		{
+			name: "vazio",
+			want: BenchStats{},
+		},
+		{
+			name:      "uma execução",
+			durations: []time.Duration{2 * ms},
+			attacks:   10,
+			want:      BenchStats{Runs: 1, Mean: 2 * ms, P50: 2 * ms, P95: 2 * ms, P99: 2 * ms, Throughput: 5000},
+		},
+		{
+			name:      "fora de ordem",
+			durations: []time.Duration{4 * ms, 2 * ms, 6 * ms, 8 * ms},
+			attacks:   40,
+			// Desvio padrão amostral de {2, 4, 6, 8} é sqrt(20/3)
+			want: BenchStats{Runs: 4, Mean: 5 * ms, StdDev: 2581988, P50: 4 * ms, P95: 8 * ms, P99: 8 * ms, Throughput: 2000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Summarize(tt.durations, tt.attacks); got != tt.want {
+				t.Errorf("Summarize() = %+v, esperado %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i + 1)
+	}
+
+	for _, p := range []float64{1, 50, 95, 99, 100} {
+		if got := percentile(sorted, p); got != time.Duration(p) {
+			t.Errorf("percentile(%v) = %d, esperado %v", p, got, p)
+		}
+	}
+	if got := percentile(sorted, 0); got != 1 {
+		t.Errorf("percentile(0) = %d, esperado 1", got)
+	}
+}
+
+func TestBenchGridPoints(t *testing.T) {
+	grid := BenchGrid{
+		MatrixSizes:  []int{2, 4},
+		NumAttacks:   []int{10},
+		NumPlayers:   []int{1, 2, 3},
+		PlayerPowers: []int{50},
+	}
+
+	points := grid.Points()
+	if len(points) != 6 {
+		t.Fatalf("%d pontos na grade, esperado 6", len(points))
+	}
+	seen := make(map[BenchPoint]bool)
+	for _, point := range points {
+		if seen[point] {
+			t.Errorf("ponto repetido na grade: %+v", point)
+		}
+		seen[point] = true
+	}
+}
+
+func TestRunBench(t *testing.T) {
+	bench := Bench{
+		Grid: BenchGrid{
+			MatrixSizes:  []int{3},
+			NumAttacks:   []int{20},
+			NumPlayers:   []int{1, 2},
+			PlayerPowers: []int{40},
+		},
+		Strategies: entity.Strategies(),
+		Runs:       3,
+		Seed:       11,
+		Clock:      tools.NewVirtualClock(time.Unix(0, 0)),
+	}
+
+	results := RunBench(context.Background(), bench)
+	if results.Interrupted || !results.VirtualClock {
+		t.Errorf("RunBench() = interrompido %v, relógio virtual %v", results.Interrupted, results.VirtualClock)
+	}
+	if want := 2 * len(bench.Strategies); len(results.Stats) != want {
+		t.Fatalf("%d estatísticas, esperado %d", len(results.Stats), want)
+	}
+	for _, stats := range results.Stats {
+		if stats.Runs != bench.Runs {
+			t.Errorf("%s: %d execuções, esperado %d", stats.Mode, stats.Runs, bench.Runs)
+		}
+		if stats.P50 > stats.P95 || stats.P95 > stats.P99 || stats.Throughput <= 0 {
+			t.Errorf("%s: estatísticas inconsistentes %+v", stats.Mode, stats)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if results := RunBench(ctx, bench); !results.Interrupted || len(results.Stats) != 0 {
+		t.Errorf("RunBench() cancelado = interrompido %v com %d estatísticas", results.Interrupted, len(results.Stats))
+	}
+}
+
+// Mede um jogo completo em cada estratégia com o relógio virtual, ou seja,
+// apenas o custo da sincronização entre os players
+func BenchmarkRun(b *testing.B) {
+	points := []BenchPoint{
+		{MatrixSize: 8, NumAttacks: 256, NumPlayers: 2, PlayerPower: 30},
+		{MatrixSize: 4, NumAttacks: 256, NumPlayers: 8, PlayerPower: 10},
+	}
+
+	for _, point := range points {
+		sequences := tools.NewSequences(tools.NewShape(point.MatrixSize, point.MatrixSize), point.NumAttacks, point.NumPlayers, 1, tools.Uniform)
+		for _, strategy := range entity.Strategies() {
+			name := fmt.Sprintf("%s/size=%d,players=%d", strategy.Name, point.MatrixSize, point.NumPlayers)
+			b.Run(name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					game := NewRunner(point.NumAttacks, point.MatrixSize, point.PlayerPower, point.NumPlayers, tools.NewVirtualClock(time.Unix(0, 0)))
+					game.SetSequences(sequences)
+					game.SetOutput(io.Discard)
+					game.Run(context.Background(), strategy)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package runner
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/brnocorreia/concurrency/internal/entity"
+	"github.com/brnocorreia/concurrency/internal/linearizability"
+	"github.com/brnocorreia/concurrency/internal/tools"
+)
+
+// Propriedade do jogo verificada no fim de uma execução com o modo caos
+type Invariant struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Held        bool   `json:"held"`
+	Detail      string `json:"detail,omitempty"`
+}
+
+// Falhas injetadas numa execução e as invariantes que sobreviveram a elas
+type ChaosResult struct {
+	Config     string            `json:"config"`
+	Faults     tools.ChaosFaults `json:"faults"`
+	Invariants []Invariant       `json:"invariants"`
+}
+
+// Lock de um bloco numa réplica
+type lockKey struct {
+	block   int
+	replica int
+}
+
+// Conta os locks pegos e ainda não liberados pelos eventos de lock. Uma
+// goroutine que entrou em pânico sem liberar o lock deixa o saldo positivo
+type lockBalance struct {
+	mutex sync.Mutex
+	held  map[lockKey]int
+}
+
+func newLockBalance() *lockBalance {
+	return &lockBalance{held: make(map[lockKey]int)}
+}
+
+func (b *lockBalance) Emit(event entity.Event) {
+	delta := 0
+	switch event.Kind {
+	case entity.EventLockAcquired:
+		delta = 1
+	case entity.EventLockReleased:
+		delta = -1
+	default:
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	key := lockKey{block: event.Block, replica: event.Replica}
+	b.held[key] += delta
+	if b.held[key] == 0 {
+		delete(b.held, key)
+	}
+}
+
+// Locks que continuam pegos, em ordem de bloco e réplica
+func (b *lockBalance) leaked() []lockKey {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	keys := make([]lockKey, 0, len(b.held))
+	for key := range b.held {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b lockKey) int {
+		return cmp.Or(cmp.Compare(a.block, b.block), cmp.Compare(a.replica, b.replica))
+	})
+	return keys
+}
+
+// Ataca o bloco recuperando o pânico injetado pelo modo caos. Qualquer outro
+// pânico é um erro de verdade e continua subindo
+func hitSafely(ctx context.Context, block entity.Block, player *entity.Player) (hit, panicked bool) {
+	defer func() {
+		if p := recover(); p != nil {
+			if p != tools.ErrChaosPanic {
+				panic(p)
+			}
+			panicked = true
+		}
+	}()
+	return block.Hit(ctx, player), false
+}
+
+// Verifica as invariantes do jogo depois das falhas. As réplicas só são
+// lidas depois que o tabuleiro foi fechado
+func (r *Runner) checkInvariants(chaos *tools.Chaos, locks *lockBalance, replicas []entity.Matrix, players []*entity.Player, divergences []entity.Divergence, history *linearizability.Report) ChaosResult {
+	result := ChaosResult{Config: chaos.Config().String(), Faults: chaos.Faults()}
+
+	leaked := locks.leaked()
+	released := Invariant{Name: "locks-released", Description: "todo lock adquirido foi liberado", Held: len(leaked) == 0}
+	if !released.Held {
+		released.Detail = fmt.Sprintf("%d locks presos, o primeiro no bloco %d da réplica %d", len(leaked), leaked[0].block, leaked[0].replica)
+	}
+
+	// Um bloco destruído em qualquer réplica conta uma vez só
+	rules := r.BlockRules()
+	destroyed := make(map[int]bool)
+	for _, matrix := range replicas {
+		for _, row := range matrix {
+			for _, block := range row {
+				if block.GetHealth() == 0 && rules.Type(block.GetId()).Name != entity.HoleBlock {
+					destroyed[block.GetId()] = true
+				}
+			}
+		}
+	}
+	points := 0
+	for _, player := range players {
+		points += player.GetPoints()
+	}
+	kills := Invariant{Name: "one-point-per-kill", Description: "cada bloco destruído rendeu um único ponto", Held: points == len(destroyed)}
+	if !kills.Held {
+		kills.Detail = fmt.Sprintf("%d pontos para %d blocos destruídos", points, len(destroyed))
+	}
+
+	converged := Invariant{Name: "replicas-converged", Description: "as réplicas convergiram", Held: len(divergences) == 0}
+	if !converged.Held {
+		converged.Detail = fmt.Sprintf("%d blocos divergentes", len(divergences))
+	}
+
+	linearizable := Invariant{Name: "linearizable", Description: "o histórico de cada bloco é linearizável", Held: len(history.Violations) == 0}
+	if !linearizable.Held {
+		linearizable.Detail = fmt.Sprintf("%d blocos não linearizáveis", len(history.Violations))
+	}
+
+	result.Invariants = []Invariant{released, kills, converged, linearizable}
+	return result
+}
+
+// Imprime as falhas injetadas e o estado de cada invariante
+func (c ChaosResult) Fprint(w io.Writer) {
+	fmt.Fprintf(w, "Caos (%s), atrasos: %d, reordenações: %d, pânicos: %d\n", c.Config, c.Faults.Delays, c.Faults.Reorders, c.Faults.Panics)
+	for _, invariant := range c.Invariants {
+		status := "ok"
+		if !invariant.Held {
+			status = "violada"
+		}
+		fmt.Fprintf(w, "  [%s] %s", status, invariant.Description)
+		if invariant.Detail != "" {
+			fmt.Fprintf(w, ": %s", invariant.Detail)
+		}
+		fmt.Fprintln(w)
+	}
+}
@@ -0,0 +1,278 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/brnocorreia/concurrency/internal/entity"
+	"github.com/brnocorreia/concurrency/internal/linearizability"
+	"github.com/brnocorreia/concurrency/internal/tools"
+	"github.com/brnocorreia/concurrency/internal/watchdog"
+)
+
+// Versão do formato do results.json. Deve ser incrementada sempre que um
+// campo existente mudar de significado ou for removido. A versão 2 trouxe os
+// tabuleiros retangulares: as dimensões ficam em width e height, e
+// matrix_size só aparece nas matrizes quadradas
+const ResultsVersion = 2
+
+// Versão mais antiga do results.json que ainda é lida
+const MinResultsVersion = 1
+
+// Documento salvo ao final de uma execução
+type Results struct {
+	Version     int       `json:"version"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Config      Config    `json:"config"`
+	// Limites das faixas dos histogramas de espera, a última faixa não tem limite
+	WaitBuckets []time.Duration `json:"wait_buckets_ns"`
+	Runs        []RunResult     `json:"runs"`
+}
+
+// Parâmetros usados em todas as execuções do documento
+type Config struct {
+	NumAttacks int `json:"num_attacks"`
+	// Lado da matriz quando ela é quadrada, ausente nos retângulos
+	MatrixSize int `json:"matrix_size,omitempty"`
+	Width      int `json:"width"`
+	Height     int `json:"height"`
+	// Linhas do tabuleiro com # nos blocos e . nos buracos, ausente quando
+	// não há buracos
+	Mask        []string `json:"mask,omitempty"`
+	PlayerPower int      `json:"player_power"`
+	NumPlayers  int      `json:"num_players"`
+	// Saúde inicial e duração dos ataques dos blocos. O poder de cada
+	// player fica no resultado dele
+	BlockHealth int           `json:"block_health"`
+	EvenHitTime time.Duration `json:"even_hit_time_ns"`
+	OddHitTime  time.Duration `json:"odd_hit_time_ns"`
+	// Nome do tipo de cada bloco, linha por linha, ausente quando todos são
+	// do tipo standard
+	BlockLayout [][]string `json:"block_layout,omitempty"`
+	// Definição dos tipos usados no layout
+	BlockTypes map[string]BlockTypeResult `json:"block_types,omitempty"`
+	// Semente das sequências de ataque, ausente quando não é conhecida
+	Seed *int64 `json:"seed,omitempty"`
+	// Distribuição dos alvos das sequências, ausente quando não é conhecida
+	Distribution string `json:"distribution,omitempty"`
+	// Falhas injetadas pelo modo caos, ausente quando ele está desligado
+	Chaos string `json:"chaos,omitempty"`
+	// Indica se as durações foram medidas com o relógio virtual
+	VirtualClock bool `json:"virtual_clock"`
+}
+
+// Tipo de bloco como foi definido. Campos zerados seguem a saúde e as
+// durações da configuração
+type BlockTypeResult struct {
+	Health  int           `json:"health,omitempty"`
+	HitTime time.Duration `json:"hit_time_ns,omitempty"`
+	Regen   int           `json:"regen,omitempty"`
+}
+
+// Converte a configuração salva numa versão anterior do formato para a atual
+func UpgradeConfig(config Config, version int) (Config, error) {
+	if version < MinResultsVersion || version > ResultsVersion {
+		return Config{}, fmt.Errorf("versão %d dos resultados não suportada", version)
+	}
+	// A versão 1 só tinha matrizes quadradas, com o lado em matrix_size
+	if version == 1 {
+		config.Width, config.Height = config.MatrixSize, config.MatrixSize
+	}
+	return config, nil
+}
+
+// Dimensões da matriz
+func (c Config) Dimensions() (width, height int) {
+	return c.Width, c.Height
+}
+
+// Formato do tabuleiro descrito pela configuração
+func (c Config) Shape() (tools.Shape, error) {
+	if len(c.Mask) > 0 {
+		return tools.ShapeFromMask(c.Mask)
+	}
+	return tools.NewShape(c.Dimensions()), nil
+}
+
+// Regras dos blocos descritas pela configuração
+func (c Config) BlockRules() entity.BlockRules {
+	rules := entity.BlockRules{Health: c.BlockHealth, EvenHitTime: c.EvenHitTime, OddHitTime: c.OddHitTime}
+	for _, row := range c.BlockLayout {
+		for _, name := range row {
+			t := c.BlockTypes[name]
+			rules.Layout = append(rules.Layout, entity.BlockType{Name: name, Health: t.Health, HitTime: t.HitTime, Regen: t.Regen})
+		}
+	}
+	if shape, err := c.Shape(); err == nil {
+		rules.Holes = shape.Holes
+	}
+	return rules.WithDefaults()
+}
+
+// Resultado da execução de uma estratégia. Interrupted indica que a execução
+// foi cancelada antes dos players terminarem e que o resultado é parcial.
+// Stalled é o diagnóstico do watchdog quando ele abortou a execução, e
+// Divergences lista os blocos com saúdes diferentes entre as réplicas
+type RunResult struct {
+	Mode        string          `json:"mode"`
+	StartedAt   time.Time       `json:"started_at"`
+	FinishedAt  time.Time       `json:"finished_at"`
+	Duration    time.Duration   `json:"duration_ns"`
+	Interrupted bool            `json:"interrupted"`
+	Players     []PlayerResult  `json:"players"`
+	Replicas    []ReplicaResult `json:"replicas"`
+	// Métricas de lock de cada bloco, somando as réplicas
+	Locks       [][]LockResult      `json:"locks"`
+	Stalled     *watchdog.Report    `json:"stalled,omitempty"`
+	Divergences []entity.Divergence `json:"divergences,omitempty"`
+	// Resultado da verificação do histórico, quando ligada
+	Linearizability *linearizability.Report `json:"linearizability,omitempty"`
+	// Falhas do modo caos e as invariantes que sobreviveram, quando ligado
+	Chaos *ChaosResult `json:"chaos,omitempty"`
+}
+
+type PlayerResult struct {
+	Id      int        `json:"id"`
+	Power   int        `json:"power"`
+	Points  int        `json:"points"`
+	Attacks int        `json:"attacks"`
+	Locks   LockResult `json:"locks"`
+}
+
+// Métricas de lock de um bloco ou de um player. WaitHistogram conta as
+// esperas em cada faixa de WaitBuckets
+type LockResult struct {
+	Acquisitions  int           `json:"acquisitions"`
+	Contended     int           `json:"contended"`
+	Wait          time.Duration `json:"wait_ns"`
+	Hold          time.Duration `json:"hold_ns"`
+	MaxWait       time.Duration `json:"max_wait_ns"`
+	WaitHistogram []int         `json:"wait_histogram"`
+}
+
+// Estado final de uma matriz do tabuleiro. Kills guarda o id do player que
+// destruiu cada bloco, ou 0 se o bloco terminou vivo
+type ReplicaResult struct {
+	Health [][]int `json:"health"`
+	Kills  [][]int `json:"kills"`
+}
+
+func newPlayerResult(player *entity.Player) PlayerResult {
+	return PlayerResult{
+		Id:      player.Id,
+		Power:   player.Power,
+		Points:  player.GetPoints(),
+		Attacks: player.GetAttacks(),
+		Locks:   newLockResult(player.GetLockStats()),
+	}
+}
+
+func newLockResult(stats entity.LockStats) LockResult {
+	return LockResult{
+		Acquisitions:  stats.Acquisitions,
+		Contended:     stats.Contended,
+		Wait:          stats.Wait,
+		Hold:          stats.Hold,
+		MaxWait:       stats.MaxWait,
+		WaitHistogram: stats.WaitHistogram[:],
+	}
+}
+
+// Soma as métricas de lock de cada bloco em todas as réplicas
+func newLockHeatmap(replicas []entity.Matrix) [][]LockResult {
+	var heatmap [][]LockResult
+	if len(replicas) == 0 {
+		return heatmap
+	}
+	for i, row := range replicas[0] {
+		heatmap = append(heatmap, make([]LockResult, len(row)))
+		for j := range row {
+			var stats entity.LockStats
+			for _, matrix := range replicas {
+				stats.Add(matrix[i][j].LockStats())
+			}
+			heatmap[i][j] = newLockResult(stats)
+		}
+	}
+	return heatmap
+}
+
+func newReplicaResult(matrix entity.Matrix) ReplicaResult {
+	return ReplicaResult{
+		Health: matrix.Healths(),
+		Kills:  matrix.Kills(),
+	}
+}
+
+// Monta o documento de resultados com a configuração do runner
+func (r *Runner) Results(runs []RunResult) Results {
+	_, virtual := r.clock.(*tools.VirtualClock)
+	matrixSize := 0
+	if r.shape.Width == r.shape.Height {
+		matrixSize = r.shape.Width
+	}
+	var layout [][]string
+	var types map[string]BlockTypeResult
+	if len(r.rules.Layout) > 0 {
+		types = make(map[string]BlockTypeResult)
+		for i, blockType := range r.rules.Layout {
+			if i%r.shape.Width == 0 {
+				layout = append(layout, nil)
+			}
+			layout[len(layout)-1] = append(layout[len(layout)-1], blockType.Name)
+			types[blockType.Name] = BlockTypeResult{Health: blockType.Health, HitTime: blockType.HitTime, Regen: blockType.Regen}
+		}
+	}
+	return Results{
+		Version:     ResultsVersion,
+		GeneratedAt: time.Now(),
+		WaitBuckets: entity.WaitBuckets[:],
+		Config: Config{
+			NumAttacks:   r.numAttacks,
+			MatrixSize:   matrixSize,
+			Width:        r.shape.Width,
+			Height:       r.shape.Height,
+			Mask:         r.shape.Mask(),
+			PlayerPower:  r.playerPower,
+			NumPlayers:   r.numPlayers,
+			BlockHealth:  r.rules.Health,
+			EvenHitTime:  r.rules.EvenHitTime,
+			OddHitTime:   r.rules.OddHitTime,
+			BlockLayout:  layout,
+			BlockTypes:   types,
+			Seed:         r.seed,
+			Distribution: r.distribution,
+			Chaos:        r.chaos.String(),
+			VirtualClock: virtual,
+		},
+		Runs: runs,
+	}
+}
+
+func SaveResults(results Results, filename string) error {
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+// Lê um documento salvo por SaveResults, de qualquer versão suportada, com a
+// configuração convertida para a versão atual
+func LoadResults(filename string) (Results, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return Results{}, err
+	}
+	var results Results
+	if err := json.Unmarshal(data, &results); err != nil {
+		return Results{}, fmt.Errorf("%s: %w", filename, err)
+	}
+	if results.Config, err = UpgradeConfig(results.Config, results.Version); err != nil {
+		return Results{}, fmt.Errorf("%s: %w", filename, err)
+	}
+	results.Version = ResultsVersion
+	return results, nil
+}
@@ -1,242 +1,499 @@
 package runner
 
 import (
+	"cmp"
+	"context"
 	"fmt"
+	"io"
+	"os"
 	"sync"
 	"time"
 
 	"github.com/brnocorreia/concurrency/internal/config/logger"
 	"github.com/brnocorreia/concurrency/internal/entity"
+	"github.com/brnocorreia/concurrency/internal/linearizability"
 	"github.com/brnocorreia/concurrency/internal/tools"
+	"github.com/brnocorreia/concurrency/internal/watchdog"
 	"go.uber.org/zap"
 )
 
 type Runner struct {
-	numAttacks  int
-	matrixSize  int
+	numAttacks int
+	// Dimensões e buracos do tabuleiro
+	shape       tools.Shape
 	playerPower int
-	sequence_1  [][2]int
-	sequence_2  [][2]int
+	numPlayers  int
+	// Poder de cada player, na ordem dos ids. Vazio quando todos usam playerPower
+	powers []int
+	rules  entity.BlockRules
+	clock  tools.Clock
+	// Arquivos de sequência de cada player, os padrões quando vazio
+	sequenceFiles []string
+	// Sequência de ataques de cada player, na ordem dos ids
+	sequences [][][2]int
+	// Semente usada para gerar as sequências, se conhecida
+	seed *int64
+	// Distribuição dos alvos das sequências, vazia se não é conhecida
+	distribution string
+	out          io.Writer
+	events       entity.Sink
+	// Janela sem eventos depois da qual o jogo é abortado, zero desliga o watchdog
+	watchdog time.Duration
+	// Intervalo da verificação contínua das réplicas, zero a desliga
+	checkInterval time.Duration
+	// Registra os ataques e verifica se o histórico de cada bloco é linearizável
+	linearizability bool
+	// Falhas injetadas nas primitivas de sincronização, desligado quando vazio
+	chaos tools.ChaosConfig
 }
 
-func NewRunner(numAttacks, matrixSize, playerPower int) *Runner {
+func NewRunner(numAttacks, matrixSize, playerPower, numPlayers int, clock tools.Clock) *Runner {
 	return &Runner{
 		numAttacks:  numAttacks,
-		matrixSize:  matrixSize,
+		shape:       tools.NewShape(matrixSize, matrixSize),
 		playerPower: playerPower,
+		numPlayers:  numPlayers,
+		rules:       entity.DefaultBlockRules,
+		clock:       clock,
+		out:         os.Stdout,
 	}
 }
 
 func (r *Runner) LoadSequence() (bool, error) {
 	logger.Info("Carregando a sequência de ataques...")
-	seq_1, err := tools.LoadSequenceFromFile("sequence_1.json")
-	if err != nil {
-		logger.Info("Erro ao carregar a sequência 1")
-		return false, err
+	sequences := make([]tools.SequenceFile, r.numPlayers)
+	for i := range sequences {
+		filename := tools.SequenceFilename(i + 1)
+		if len(r.sequenceFiles) > 0 {
+			filename = r.sequenceFiles[i]
+		}
+		sequence, err := tools.LoadValidSequenceFile(filename, r.shape)
+		if err != nil {
+			logger.Info(fmt.Sprintf("Erro ao carregar a sequência %d", i+1))
+			return false, err
+		}
+		sequences[i] = sequence
 	}
-	r.sequence_1 = seq_1
 
-	seq_2, err := tools.LoadSequenceFromFile("sequence_2.json")
-	if err != nil {
-		logger.Info("Erro ao carregar a sequência 2")
-		return false, err
-	}
-	r.sequence_2 = seq_2
+	r.SetSequences(sequences)
 	return true, nil
 }
 
-func (r *Runner) RunMutex() {
+// Usa as sequências informadas, uma por player na ordem dos ids, sem passar
+// pelos arquivos
+func (r *Runner) SetSequences(sequences []tools.SequenceFile) {
+	r.sequences = make([][][2]int, len(sequences))
+	r.seed = nil
+	r.distribution = ""
+
+	// A semente e a distribuição só são conhecidas se todas as sequências
+	// vieram da mesma geração
+	seedKnown := true
+	var seed int64
+	for i, sequence := range sequences {
+		r.sequences[i] = sequence.Attacks
+
+		if sequence.Version == 0 || (i > 0 && sequence.Seed != seed) {
+			seedKnown = false
+		}
+		seed = sequence.Seed
+	}
 
-	logger.Info("Iniciando o jogo para versão MUTEX...")
+	if seedKnown {
+		r.seed = &seed
+		// Arquivos anteriores às distribuições não a registram e são uniformes
+		if len(sequences) > 0 {
+			r.distribution = cmp.Or(sequences[0].Distribution, tools.DistributionUniform)
+		}
+	}
+}
 
-	// Cria a matriz de blocos
-	matrix := entity.NewMatrixMutex(r.matrixSize, r.matrixSize)
+// Define o poder de cada player, na ordem dos ids. A quantidade de players
+// passa a ser o tamanho da lista
+func (r *Runner) SetPowers(powers []int) {
+	r.powers = powers
+	r.numPlayers = len(powers)
+}
 
-	logger.Info("Criando os jogadores...")
-	// Cria jogadores
-	player1 := entity.NewPlayer(1, r.playerPower)
-	player2 := entity.NewPlayer(2, r.playerPower)
+// Define os arquivos de onde o LoadSequence lê a sequência de cada player,
+// na ordem dos ids, no lugar dos arquivos padrão
+func (r *Runner) SetSequenceFiles(files []string) {
+	r.sequenceFiles = files
+}
 
-	var wg sync.WaitGroup
+// Define a saúde inicial, a duração dos ataques e o tipo de cada bloco
+func (r *Runner) SetBlockRules(rules entity.BlockRules) {
+	r.rules = rules.WithDefaults()
+}
 
-	results := make(chan string, 2)
+// Define as dimensões e os buracos do tabuleiro, que substituem o tamanho
+// passado ao NewRunner
+func (r *Runner) SetShape(shape tools.Shape) {
+	r.shape = shape
+}
 
-	// Função para simular um ataque
-	attack := func(player *entity.Player, sequence [][2]int) {
-		defer wg.Done()
-		for _, coord := range sequence {
-			x, y := coord[0], coord[1]
-			block := matrix[x][y]
-			block.Hit(player)
-		}
+func (r *Runner) Shape() tools.Shape {
+	return r.shape
+}
 
-		result := fmt.Sprintf("O player %d ganhou %d pontos\n", player.Id, player.GetPoints())
-		results <- result
+// Regras dos blocos com os buracos do tabuleiro
+func (r *Runner) BlockRules() entity.BlockRules {
+	rules := r.rules
+	rules.Holes = r.shape.Holes
+	return rules
+}
+
+// Poder do player com o id informado
+func (r *Runner) power(id int) int {
+	if len(r.powers) > 0 {
+		return r.powers[id-1]
 	}
+	return r.playerPower
+}
 
-	// Inicia as goroutines para os dois jogadores
-	logger.Info("Iniciando as goroutines...")
-	wg.Add(2)
-	init := time.Now()
-	go attack(player1, r.sequence_1)
-	go attack(player2, r.sequence_2)
+// Define onde o estado final do jogo é impresso, os.Stdout por padrão
+func (r *Runner) SetOutput(out io.Writer) {
+	r.out = out
+}
 
-	// Aguarda até que ambas as goroutines terminem
-	wg.Wait()
-	duration := (time.Since(init))
+// Define quem recebe os eventos dos blocos durante o jogo. Pode ser nil
+func (r *Runner) SetEvents(events entity.Sink) {
+	r.events = events
+}
 
-	logger.Info("Tempo de execução [MUTEX]:", zap.Duration("duration", duration))
+// Liga o watchdog, que aborta o jogo quando nenhum evento acontece durante
+// a janela. Zero desliga o watchdog
+func (r *Runner) SetWatchdog(window time.Duration) {
+	r.watchdog = window
+}
 
-	close(results)
+// Liga a comparação das réplicas durante o jogo, repetida a cada intervalo.
+// Zero a desliga, e as réplicas são comparadas apenas no fim do jogo
+func (r *Runner) SetConsistencyCheck(interval time.Duration) {
+	r.checkInterval = interval
+}
 
-	// TODO: Armazenar o estado final dos blocos num arquivo/log
-	// Imprime o estado final dos blocos
-	fmt.Println("------------------------------------------------")
-	fmt.Println()
-	fmt.Println("Estado final dos blocos:")
-	entity.PrintBlocksMutex(matrix)
-	fmt.Println()
-	fmt.Println("------------------------------------------------")
+// Liga o registro dos ataques e a verificação de linearizabilidade no fim
+// do jogo
+func (r *Runner) SetLinearizability(check bool) {
+	r.linearizability = check
+}
 
-	for result := range results {
-		logger.Info(result)
-		fmt.Println(result)
-	}
-	logger.Info("Finalizando o jogo para versão MUTEX...")
+// Liga o modo caos, que injeta as falhas em cada jogo e informa quais
+// invariantes sobreviveram a elas
+func (r *Runner) SetChaos(config tools.ChaosConfig) {
+	r.chaos = config
 }
 
-func (r *Runner) RunSemaphore() {
-	logger.Info("Iniciando o jogo para versão SEMAPHORE...")
+// Executa o jogo usando a estratégia de sincronização informada. Se o
+// contexto for cancelado, os players param de atacar e o resultado parcial
+// é retornado com Interrupted marcado. Se o watchdog disparar, o jogo é
+// abortado e o diagnóstico fica em Stalled
+func (r *Runner) Run(ctx context.Context, strategy entity.Strategy) RunResult {
+	logger.Info(fmt.Sprintf("Iniciando o jogo para versão %s...", strategy.Label))
 
-	// Cria a matriz de blocos
-	matrix := entity.NewMatrixSemaphore(r.matrixSize, r.matrixSize)
+	var sinks entity.Sinks
+	if r.events != nil {
+		sinks = append(sinks, r.events)
+	}
+	var dog *watchdog.Watchdog
+	if r.watchdog > 0 {
+		dog = watchdog.New(r.watchdog)
+		sinks = append(sinks, dog)
+	}
+	// O modo caos também verifica a linearizabilidade e os locks presos
+	var chaos *tools.Chaos
+	var locks *lockBalance
+	if r.chaos.Enabled() {
+		chaos = tools.NewChaos(r.chaos)
+		locks = newLockBalance()
+		sinks = append(sinks, locks)
+	}
+	var history *linearizability.History
+	if r.linearizability || chaos != nil {
+		history = linearizability.NewHistory()
+		sinks = append(sinks, history)
+	}
+	var events entity.Sink
+	switch len(sinks) {
+	case 0:
+	case 1:
+		events = sinks[0]
+	default:
+		events = sinks
+	}
+
+	// Cria o tabuleiro de blocos
+	board := strategy.NewBoard(entity.BoardConfig{
+		Width:   r.shape.Width,
+		Height:  r.shape.Height,
+		Clock:   r.clock,
+		Players: r.numPlayers,
+		Blocks:  r.BlockRules(),
+		Events:  events,
+		Chaos:   chaos,
+	})
+	// Um tabuleiro travado não consegue ser fechado
+	var stalled *watchdog.Report
+	defer func() {
+		if stalled == nil {
+			board.Close()
+		}
+	}()
+
+	// Os players que ainda não travaram param quando o jogo é abortado
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	logger.Info("Criando os jogadores...")
 	// Cria jogadores
-	player1 := entity.NewPlayer(1, r.playerPower)
-	player2 := entity.NewPlayer(2, r.playerPower)
+	players := make([]*entity.Player, r.numPlayers)
+	for i := range players {
+		players[i] = entity.NewPlayer(i+1, r.power(i+1))
+	}
 
 	var wg sync.WaitGroup
 
-	results := make(chan string, 2)
+	results := make(chan string, r.numPlayers)
+	// Ataques que entraram em pânico, que não chegam a contar para o player
+	panics := make([]int, r.numPlayers)
 
-	// Função para simular um ataque
-	attack := func(player *entity.Player, sequence [][2]int) {
+	// Função para simular um ataque. Cada player mede o tempo no seu próprio contexto
+	attack := func(ctx context.Context, player *entity.Player, sequence [][2]int) {
 		defer wg.Done()
 		for _, coord := range sequence {
+			if ctx.Err() != nil {
+				break
+			}
 			x, y := coord[0], coord[1]
-			block := matrix[x][y]
-			block.Hit(player)
+			block := board.Block(player, x, y)
+			if history == nil {
+				block.Hit(ctx, player)
+				continue
+			}
+			op := history.Invoke(player.Id, block.GetId(), player.GetDamage())
+			// Um ataque interrompido que não acertou pode não ter visto o
+			// bloco, e um que entrou em pânico não chegou a causar dano
+			hit, panicked := hitSafely(ctx, block, player)
+			if panicked {
+				panics[player.Id-1]++
+			} else if hit || ctx.Err() == nil {
+				history.Return(op, hit)
+			}
 		}
 
 		result := fmt.Sprintf("O player %d ganhou %d pontos\n", player.Id, player.GetPoints())
 		results <- result
 	}
 
-	// Inicia as goroutines para os dois jogadores
+	// Inicia uma goroutine para cada jogador
 	logger.Info("Iniciando as goroutines...")
-	wg.Add(2)
-	init := time.Now()
-	go attack(player1, r.sequence_1)
-	go attack(player2, r.sequence_2)
-
-	// Aguarda até que ambas as goroutines terminem
-	wg.Wait()
-	duration := (time.Since(init))
+	wg.Add(len(players))
+	init := r.clock.Now(ctx)
+	timelines := make([]context.Context, len(players))
+	for i, player := range players {
+		timelines[i] = tools.WithTimeline(ctx, init)
+		go attack(timelines[i], player, r.sequences[i])
+	}
 
-	logger.Info("Tempo de execução [SEMAPHORE]:", zap.Duration("duration", duration))
+	// Compara as réplicas periodicamente enquanto os players atacam
+	stopCheck := make(chan struct{})
+	checked := make(chan []entity.Divergence, 1)
+	if checker, ok := board.(entity.ReplicaChecker); ok && r.checkInterval > 0 {
+		go watchReplicas(checker, r.checkInterval, stopCheck, checked)
+	} else {
+		checked <- nil
+	}
 
+	// Aguarda até que todas as goroutines terminem
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	if dog != nil {
+		stalled = dog.Watch(done)
+	} else {
+		<-done
+	}
+	close(stopCheck)
+	if stalled != nil {
+		cancel()
+		return r.abort(strategy, players, init, finished(r.clock, init, timelines), stalled)
+	}
+	divergences := <-checked
+	board.Close()
 	close(results)
 
-	// Imprime o estado final dos blocos
-	fmt.Println("------------------------------------------------")
-	fmt.Println()
-	fmt.Println("Estado final dos blocos:")
-	entity.PrintBlocksSemaphore(matrix)
-	fmt.Println()
-	fmt.Println("------------------------------------------------")
-
-	for result := range results {
-		logger.Info(result)
-		fmt.Println(result)
+	finish := finished(r.clock, init, timelines)
+	duration := finish.Sub(init)
+	// Só foi interrompido o jogo em que algum player deixou ataques sem
+	// concluir, um cancelamento depois do último ataque não muda o resultado
+	interrupted := false
+	for i, player := range players {
+		if player.GetAttacks()+panics[i] < len(r.sequences[i]) {
+			interrupted = true
+		}
 	}
-	logger.Info("Finalizando o jogo para versão SEMAPHORE...")
-}
-
-func (r *Runner) RunMessages() {
-	logger.Info("Iniciando o jogo para versão TROCA DE MENSAGENS...")
 
-	// Cria a matriz de blocos
-	matrix_1 := entity.NewMatrixMessage(r.matrixSize, r.matrixSize)
-	matrix_2 := entity.NewMatrixMessage(r.matrixSize, r.matrixSize)
-
-	logger.Info("Criando os jogadores...")
-	// Cria jogadores
-	player1 := entity.NewPlayer(1, r.playerPower)
-	player2 := entity.NewPlayer(2, r.playerPower)
-
-	var wg sync.WaitGroup
+	logger.Info(fmt.Sprintf("Tempo de execução [%s]:", strategy.Label), zap.Duration("duration", duration))
+	if interrupted {
+		logger.Info(fmt.Sprintf("O jogo para versão %s foi interrompido, os resultados são parciais", strategy.Label))
+		for _, player := range players {
+			fmt.Fprintf(r.out, "O player %d concluiu %d de %d ataques\n", player.Id, player.GetAttacks(), len(r.sequences[player.Id-1]))
+		}
+	}
 
-	results := make(chan string, 2)
-	lockSync := make(chan [4]int, 2)
-	updates := make(chan [4]int, 2)
+	// Imprime o estado final dos blocos
+	replicas := board.Replicas()
+	rules := r.BlockRules()
+	for i, matrix := range replicas {
+		fmt.Fprintln(r.out, "------------------------------------------------")
+		fmt.Fprintln(r.out)
+		if len(replicas) == 1 {
+			fmt.Fprintln(r.out, "Estado final dos blocos:")
+		} else {
+			fmt.Fprintf(r.out, "Estado final da matriz %d:\n", i+1)
+		}
+		entity.FprintBlocks(r.out, matrix, rules)
+		fmt.Fprintln(r.out)
+		fmt.Fprintln(r.out, "------------------------------------------------")
+	}
 
-	// Função para simular um ataque
-	attack := func(player *entity.Player, sequence [][2]int, matrix entity.MatrixMessage) {
-		defer wg.Done()
-		for _, coord := range sequence {
-			x, y := coord[0], coord[1]
-			block := matrix[x][y]
-			block.Hit(player, lockSync, updates, x, y)
+	// Com as atualizações aplicadas, toda réplica deve ter chegado ao mesmo estado
+	divergences = append(divergences, entity.CompareReplicas(replicas)...)
+	if len(replicas) > 1 {
+		if len(divergences) > 0 {
+			logger.Error(fmt.Sprintf("As réplicas da versão %s divergem", strategy.Label), fmt.Errorf("%d blocos divergentes", len(divergences)))
+			fmt.Fprintln(r.out, "As réplicas divergem:")
+			entity.FprintDivergences(r.out, divergences)
+		} else {
+			fmt.Fprintln(r.out, "As réplicas convergiram")
 		}
+		fmt.Fprintln(r.out)
+	}
 
-		result := fmt.Sprintf("O player %d ganhou %d pontos\n", player.Id, player.GetPoints())
-		results <- result
+	var linearizable *linearizability.Report
+	if history != nil {
+		report := linearizability.Check(history.Operations(), r.BlockRules())
+		linearizable = &report
+	}
+	if r.linearizability {
+		if len(linearizable.Violations) > 0 {
+			logger.Error(fmt.Sprintf("O histórico da versão %s não é linearizável", strategy.Label), fmt.Errorf("%d blocos com violações", len(linearizable.Violations)))
+		}
+		linearizable.Fprint(r.out)
+		fmt.Fprintln(r.out)
 	}
 
-	logger.Info("Iniciando as goroutines...")
-	// Inicia as goroutines de sincronização
-	// wg.Add(1)
-	go entity.SyncLocks(lockSync, matrix_1, matrix_2)
-
-	// Inicia as goroutines de atualização
-	// wg.Add(1)
-	go entity.UpdateMatrix(updates, matrix_1, matrix_2)
-
-	// Inicia as goroutines para os dois jogadores
-	wg.Add(2)
-	init := time.Now()
-	go attack(player1, r.sequence_1, matrix_1)
-	go attack(player2, r.sequence_2, matrix_2)
-
-	// Aguarda até que ambas as goroutines terminem
-	wg.Wait()
-	close(lockSync)
-	close(updates)
-	close(results)
+	var survived *ChaosResult
+	if chaos != nil {
+		report := r.checkInvariants(chaos, locks, replicas, players, divergences, linearizable)
+		survived = &report
+		report.Fprint(r.out)
+		fmt.Fprintln(r.out)
+	}
 
-	duration := (time.Since(init))
+	for result := range results {
+		logger.Info(result)
+		fmt.Fprintln(r.out, result)
+	}
+	for _, player := range players {
+		locks := player.GetLockStats()
+		fmt.Fprintf(r.out, "O player %d esperou %v pelos blocos, em %d de %d acessos disputados\n", player.Id, locks.Wait, locks.Contended, locks.Acquisitions)
+	}
+	logger.Info(fmt.Sprintf("Finalizando o jogo para versão %s...", strategy.Label))
+
+	result := RunResult{
+		Mode:        strategy.Name,
+		StartedAt:   init,
+		FinishedAt:  finish,
+		Duration:    duration,
+		Interrupted: interrupted,
+	}
+	for _, player := range players {
+		result.Players = append(result.Players, newPlayerResult(player))
+	}
+	for _, matrix := range replicas {
+		result.Replicas = append(result.Replicas, newReplicaResult(matrix))
+	}
+	result.Locks = newLockHeatmap(replicas)
+	result.Divergences = divergences
+	if r.linearizability {
+		result.Linearizability = linearizable
+	}
+	result.Chaos = survived
+	return result
+}
 
-	logger.Info("Tempo de execução [TROCA DE MENSAGENS]:", zap.Duration("duration", duration))
+// Fim do jogo, o instante do player que terminou por último. No relógio
+// virtual cada player tem a sua linha do tempo
+func finished(clock tools.Clock, init time.Time, timelines []context.Context) time.Time {
+	finish := init
+	for _, ctx := range timelines {
+		if now := clock.Now(ctx); now.After(finish) {
+			finish = now
+		}
+	}
+	return finish
+}
 
-	// Imprime o estado final dos blocos
-	fmt.Println("------------------------------------------------")
-	fmt.Println()
-	fmt.Println("Estado final da matriz 1:")
-	entity.PrintBlocksMessage(matrix_1)
-	fmt.Println()
-	fmt.Println("------------------------------------------------")
-	// Imprime o estado final dos blocos
-	fmt.Println("------------------------------------------------")
-	fmt.Println()
-	fmt.Println("Estado final da matriz 2:")
-	entity.PrintBlocksMessage(matrix_2)
-	fmt.Println()
-	fmt.Println("------------------------------------------------")
+// Compara as réplicas a cada intervalo até stop ser fechado e envia as
+// divergências encontradas, só a primeira de cada bloco
+func watchReplicas(checker entity.ReplicaChecker, interval time.Duration, stop <-chan struct{}, checked chan<- []entity.Divergence) {
+	var divergences []entity.Divergence
+	seen := make(map[int]bool)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			checked <- divergences
+			return
+		case <-ticker.C:
+			for _, divergence := range checker.CheckReplicas() {
+				if !seen[divergence.Block] {
+					seen[divergence.Block] = true
+					divergences = append(divergences, divergence)
+				}
+			}
+		}
+	}
+}
 
-	for result := range results {
-		logger.Info(result)
-		fmt.Println(result)
+// Monta o resultado de um jogo abortado pelo watchdog. As goroutines
+// travadas continuam segurando os locks, então os blocos não são lidos e as
+// réplicas ficam de fora do resultado. Os players que não travaram também
+// podem continuar rodando até verem o cancelamento, então o progresso de cada
+// um vem do que o watchdog contou, e não dos próprios players
+func (r *Runner) abort(strategy entity.Strategy, players []*entity.Player, init, finish time.Time, stalled *watchdog.Report) RunResult {
+	logger.Error(fmt.Sprintf("O jogo para versão %s foi abortado pelo watchdog", strategy.Label), fmt.Errorf("nenhum evento em %v", stalled.Idle))
+
+	fmt.Fprintln(r.out, "------------------------------------------------")
+	fmt.Fprintf(r.out, "O jogo para versão %s foi abortado pelo watchdog\n", strategy.Label)
+	stalled.Fprint(r.out, true)
+	progress := make(map[int]watchdog.Progress)
+	for _, p := range stalled.Players {
+		progress[p.Player] = p
+	}
+	for _, player := range players {
+		fmt.Fprintf(r.out, "O player %d começou %d de %d ataques\n", player.Id, progress[player.Id].Started, len(r.sequences[player.Id-1]))
+	}
+	fmt.Fprintln(r.out, "------------------------------------------------")
+
+	result := RunResult{
+		Mode:        strategy.Name,
+		StartedAt:   init,
+		FinishedAt:  finish,
+		Duration:    finish.Sub(init),
+		Interrupted: true,
+		Stalled:     stalled,
+	}
+	// Sem as métricas de lock, que só a goroutine do player atualiza. Attacks
+	// conta os ataques começados, inclusive o que travou
+	for _, player := range players {
+		p := progress[player.Id]
+		result.Players = append(result.Players, PlayerResult{Id: player.Id, Power: player.Power, Points: p.Points, Attacks: p.Started})
 	}
-	logger.Info("Finalizando o jogo para versão TROCA DE MENSAGENS...")
+	return result
 }
@@ -0,0 +1,582 @@
+package runner
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brnocorreia/concurrency/internal/entity"
+	"github.com/brnocorreia/concurrency/internal/tools"
+	"github.com/brnocorreia/concurrency/internal/watchdog"
+)
+
+// Cria um runner com o relógio virtual e sequências geradas com a semente
+// informada num diretório temporário
+func newTestRunner(t *testing.T, numAttacks, matrixSize, playerPower, numPlayers int, seed int64) *Runner {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	if _, err := tools.Generate(tools.NewShape(matrixSize, matrixSize), numAttacks, numPlayers, seed, tools.Uniform); err != nil {
+		t.Fatal(err)
+	}
+	game := NewRunner(numAttacks, matrixSize, playerPower, numPlayers, tools.NewVirtualClock(time.Unix(0, 0)))
+	if _, err := game.LoadSequence(); err != nil {
+		t.Fatal(err)
+	}
+	if game.seed == nil || *game.seed != seed {
+		t.Fatalf("a semente das sequências não foi carregada")
+	}
+	return game
+}
+
+// Verifica as invariantes que valem para qualquer estratégia: nenhuma saúde
+// fora de [0, 100] e todo bloco destruído tem um player responsável
+func checkReplicas(t *testing.T, result RunResult, numPlayers int) {
+	t.Helper()
+	for r, replica := range result.Replicas {
+		for i, row := range replica.Health {
+			for j, health := range row {
+				killer := replica.Kills[i][j]
+				if health < 0 || health > 100 {
+					t.Errorf("réplica %d: bloco [%d][%d] com saúde %d", r+1, i, j, health)
+				}
+				if health == 0 && (killer < 1 || killer > numPlayers) {
+					t.Errorf("réplica %d: bloco [%d][%d] destruído pelo player %d", r+1, i, j, killer)
+				}
+			}
+		}
+	}
+}
+
+// Com uma única matriz, os pontos de cada player são exatamente os blocos
+// que ele destruiu e o total de pontos é o número de blocos destruídos
+func checkPoints(t *testing.T, result RunResult) {
+	t.Helper()
+	replica := result.Replicas[0]
+
+	kills := make(map[int]int)
+	dead := 0
+	for i, row := range replica.Health {
+		for j, health := range row {
+			killer := replica.Kills[i][j]
+			if health == 0 {
+				dead++
+				kills[killer]++
+			} else if killer != 0 {
+				t.Errorf("bloco [%d][%d] vivo com killer %d", i, j, killer)
+			}
+		}
+	}
+
+	points := 0
+	for _, player := range result.Players {
+		points += player.Points
+		if player.Points != kills[player.Id] {
+			t.Errorf("player %d tem %d pontos, mas destruiu %d blocos", player.Id, player.Points, kills[player.Id])
+		}
+	}
+	if points != dead {
+		t.Errorf("total de %d pontos para %d blocos destruídos", points, dead)
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name        string
+		numAttacks  int
+		matrixSize  int
+		playerPower int
+		numPlayers  int
+	}{
+		{name: "um player", numAttacks: 100, matrixSize: 3, playerPower: 40, numPlayers: 1},
+		{name: "dois players", numAttacks: 200, matrixSize: 4, playerPower: 10, numPlayers: 2},
+		{name: "muitos players", numAttacks: 100, matrixSize: 3, playerPower: 25, numPlayers: 8},
+		{name: "matriz de um bloco", numAttacks: 50, matrixSize: 1, playerPower: 15, numPlayers: 4},
+	}
+
+	for _, tt := range tests {
+		game := newTestRunner(t, tt.numAttacks, tt.matrixSize, tt.playerPower, tt.numPlayers, 99)
+		for _, strategy := range entity.Strategies() {
+			t.Run(tt.name+"/"+strategy.Name, func(t *testing.T) {
+				result := game.Run(context.Background(), strategy)
+
+				if result.Mode != strategy.Name || result.Interrupted {
+					t.Errorf("Run() = modo %q, interrompido %v", result.Mode, result.Interrupted)
+				}
+				if len(result.Players) != tt.numPlayers {
+					t.Fatalf("%d players no resultado, esperado %d", len(result.Players), tt.numPlayers)
+				}
+				for _, player := range result.Players {
+					if player.Attacks != tt.numAttacks {
+						t.Errorf("player %d concluiu %d ataques, esperado %d", player.Id, player.Attacks, tt.numAttacks)
+					}
+				}
+
+				checkReplicas(t, result, tt.numPlayers)
+				// As réplicas da troca de mensagens podem divergir entre si
+				if len(result.Replicas) == 1 {
+					checkPoints(t, result)
+				}
+			})
+		}
+	}
+}
+
+// Com um único player não há disputa, então todas as estratégias devem
+// chegar exatamente ao mesmo resultado
+func TestRunSinglePlayerAgreement(t *testing.T) {
+	game := newTestRunner(t, 150, 4, 30, 1, 7)
+
+	var want RunResult
+	for i, strategy := range entity.Strategies() {
+		result := game.Run(context.Background(), strategy)
+		if i == 0 {
+			want = result
+			continue
+		}
+		// As métricas de lock dependem da estratégia
+		got, expected := result.Players[0], want.Players[0]
+		if got.Points != expected.Points || got.Attacks != expected.Attacks {
+			t.Errorf("%s: player %+v, %s: player %+v", strategy.Name, got, want.Mode, expected)
+		}
+		if got.Locks.Contended != 0 {
+			t.Errorf("%s: %d acessos disputados com um único player", strategy.Name, got.Locks.Contended)
+		}
+		for i := range want.Replicas[0].Health {
+			for j := range want.Replicas[0].Health[i] {
+				if result.Replicas[0].Health[i][j] != want.Replicas[0].Health[i][j] {
+					t.Errorf("%s: bloco [%d][%d] com saúde %d, esperado %d", strategy.Name, i, j,
+						result.Replicas[0].Health[i][j], want.Replicas[0].Health[i][j])
+				}
+			}
+		}
+	}
+}
+
+// Cada acesso de um player aparece uma vez nas métricas dele e uma vez nas
+// do bloco, e cada acesso entra em uma única faixa do histograma
+func TestRunLockMetrics(t *testing.T) {
+	game := newTestRunner(t, 60, 3, 20, 4, 9)
+
+	for _, strategy := range entity.Strategies() {
+		t.Run(strategy.Name, func(t *testing.T) {
+			result := game.Run(context.Background(), strategy)
+
+			var players, contended int
+			for _, player := range result.Players {
+				locks := player.Locks
+				// No modo atômico o ataque a um bloco já destruído não chega ao CAS
+				if locks.Acquisitions != player.Attacks && (strategy.Name != entity.AtomicStrategy.Name || locks.Acquisitions > player.Attacks) {
+					t.Errorf("player %d com %d acessos e %d ataques", player.Id, locks.Acquisitions, player.Attacks)
+				}
+				if sum(locks.WaitHistogram) != locks.Acquisitions {
+					t.Errorf("player %d com %d esperas no histograma e %d acessos", player.Id, sum(locks.WaitHistogram), locks.Acquisitions)
+				}
+				if locks.Contended > locks.Acquisitions || locks.MaxWait > locks.Wait {
+					t.Errorf("player %d com métricas inconsistentes: %+v", player.Id, locks)
+				}
+				players += locks.Acquisitions
+				contended += locks.Contended
+			}
+
+			var blocks, blocksContended int
+			for _, row := range result.Locks {
+				for _, locks := range row {
+					blocks += locks.Acquisitions
+					blocksContended += locks.Contended
+				}
+			}
+			// Na troca de mensagens os blocos também contam os locks das goroutines auxiliares
+			if len(result.Replicas) > 1 {
+				if blocks < players {
+					t.Errorf("%d acessos nos blocos, menos que os %d dos players", blocks, players)
+				}
+				return
+			}
+			if blocks != players || blocksContended != contended {
+				t.Errorf("blocos com %d acessos (%d disputados), players com %d (%d disputados)", blocks, blocksContended, players, contended)
+			}
+		})
+	}
+}
+
+func sum(values []int) int {
+	total := 0
+	for _, value := range values {
+		total += value
+	}
+	return total
+}
+
+// Num tabuleiro retangular com buracos, os buracos nunca são atacados e os
+// resultados guardam o formato
+func TestRunShape(t *testing.T) {
+	shape, err := tools.ShapeFromMask([]string{"##.##", "#...#", "#####"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	game := NewRunner(60, 0, 30, 3, tools.NewVirtualClock(time.Unix(0, 0)))
+	game.SetShape(shape)
+	game.SetSequences(tools.NewSequences(shape, 60, 3, 17, tools.Uniform))
+	game.SetOutput(io.Discard)
+
+	var runs []RunResult
+	for _, strategy := range entity.Strategies() {
+		t.Run(strategy.Name, func(t *testing.T) {
+			result := game.Run(context.Background(), strategy)
+			runs = append(runs, result)
+			for r, replica := range result.Replicas {
+				if len(replica.Health) != 3 || len(replica.Health[0]) != 5 {
+					t.Fatalf("réplica %d com %dx%d blocos, esperado 5x3", r+1, len(replica.Health[0]), len(replica.Health))
+				}
+				for i, row := range replica.Health {
+					for j, health := range row {
+						if !shape.Contains(i, j) && (health != 0 || replica.Kills[i][j] != 0) {
+							t.Errorf("réplica %d: buraco [%d][%d] com saúde %d e destruído por %d", r+1, i, j, health, replica.Kills[i][j])
+						}
+					}
+				}
+			}
+		})
+	}
+
+	config := game.Results(runs).Config
+	if config.Width != 5 || config.Height != 3 || config.MatrixSize != 0 {
+		t.Errorf("configuração %dx%d com lado %d, esperado 5x3 sem lado", config.Width, config.Height, config.MatrixSize)
+	}
+	got, err := config.Shape()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, shape) {
+		t.Errorf("formato %v, esperado %v", got, shape)
+	}
+}
+
+func TestRunInterrupted(t *testing.T) {
+	game := newTestRunner(t, 100, 3, 10, 3, 5)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, strategy := range entity.Strategies() {
+		t.Run(strategy.Name, func(t *testing.T) {
+			result := game.Run(ctx, strategy)
+			if !result.Interrupted {
+				t.Error("Run() com o contexto cancelado não marcou Interrupted")
+			}
+			for _, player := range result.Players {
+				if player.Attacks != 0 || player.Points != 0 {
+					t.Errorf("player %d atacou com o contexto cancelado: %+v", player.Id, player)
+				}
+			}
+			checkReplicas(t, result, 3)
+		})
+	}
+}
+
+// Tabuleiro que cancela o contexto do jogo ao ser fechado, depois que todos
+// os players terminaram
+type cancelOnClose struct {
+	entity.Board
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() {
+	b.cancel()
+	b.Board.Close()
+}
+
+// Um cancelamento que chega depois do último ataque não interrompe o jogo
+func TestRunCancelledAfterCompletion(t *testing.T) {
+	game := newTestRunner(t, 100, 3, 10, 3, 5)
+
+	for _, strategy := range entity.Strategies() {
+		t.Run(strategy.Name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			wrapped := strategy
+			wrapped.NewBoard = func(cfg entity.BoardConfig) entity.Board {
+				return cancelOnClose{Board: strategy.NewBoard(cfg), cancel: cancel}
+			}
+
+			result := game.Run(ctx, wrapped)
+			if ctx.Err() == nil {
+				t.Fatal("o tabuleiro não foi fechado")
+			}
+			if result.Interrupted {
+				t.Error("Run() marcou Interrupted num jogo que terminou")
+			}
+			for _, player := range result.Players {
+				if player.Attacks != 100 {
+					t.Errorf("player %d com %d ataques, esperado 100", player.Id, player.Attacks)
+				}
+			}
+		})
+	}
+}
+
+// Tabuleiro que trava com uma inversão na ordem dos locks: o player 1 segura
+// o lock do bloco 1 e espera o do bloco 2, e o player 2 faz o contrário
+type deadlockBoard struct {
+	locks  [2]sync.Mutex
+	held   sync.WaitGroup
+	events entity.Sink
+}
+
+func (b *deadlockBoard) Block(player *entity.Player, x, y int) entity.Block {
+	return deadlockBlock{board: b}
+}
+
+func (b *deadlockBoard) Replicas() []entity.Matrix { return nil }
+
+func (b *deadlockBoard) Close() {}
+
+func (b *deadlockBoard) lock(player, block int) {
+	goroutine := entity.PlayerGoroutine(player)
+	b.events.Emit(entity.Event{Kind: entity.EventAttempt, Player: player, Block: block, Health: entity.UnknownHealth, Goroutine: goroutine})
+	b.locks[block-1].Lock()
+	b.events.Emit(entity.Event{Kind: entity.EventLockAcquired, Player: player, Block: block, Goroutine: goroutine})
+}
+
+type deadlockBlock struct {
+	entity.Block
+	board *deadlockBoard
+}
+
+func (b deadlockBlock) Hit(ctx context.Context, player *entity.Player) bool {
+	b.board.lock(player.Id, player.Id)
+	b.board.held.Done()
+	b.board.held.Wait()
+	b.board.lock(player.Id, 3-player.Id)
+	return true
+}
+
+func TestRunWatchdog(t *testing.T) {
+	deadlock := entity.Strategy{
+		Name:  "deadlock",
+		Label: "DEADLOCK",
+		NewBoard: func(cfg entity.BoardConfig) entity.Board {
+			board := &deadlockBoard{events: cfg.Events}
+			board.held.Add(cfg.Players)
+			return board
+		},
+	}
+
+	game := NewRunner(1, 1, 10, 2, tools.NewVirtualClock(time.Unix(0, 0)))
+	game.SetSequences([]tools.SequenceFile{{Attacks: [][2]int{{0, 0}}}, {Attacks: [][2]int{{0, 0}}}})
+	game.SetOutput(io.Discard)
+	game.SetWatchdog(50 * time.Millisecond)
+
+	result := game.Run(context.Background(), deadlock)
+	if result.Stalled == nil || !result.Interrupted {
+		t.Fatalf("Run() = %+v, esperado o jogo abortado pelo watchdog", result)
+	}
+	wantWaits := []watchdog.Wait{
+		{Goroutine: "player-1", Block: 2, Holder: "player-2"},
+		{Goroutine: "player-2", Block: 1, Holder: "player-1"},
+	}
+	if !reflect.DeepEqual(result.Stalled.Waits, wantWaits) {
+		t.Errorf("Waits = %+v, esperado %+v", result.Stalled.Waits, wantWaits)
+	}
+	if want := []string{"player-1", "player-2", "player-1"}; !reflect.DeepEqual(result.Stalled.Cycle, want) {
+		t.Errorf("Cycle = %v, esperado %v", result.Stalled.Cycle, want)
+	}
+	if len(result.Players) != 2 || result.Replicas != nil {
+		t.Errorf("resultado abortado com %d players e réplicas %v", len(result.Players), result.Replicas)
+	}
+	// Cada player travado começou os ataques aos dois locks do tabuleiro
+	for _, player := range result.Players {
+		if player.Attacks != 2 || player.Points != 0 {
+			t.Errorf("player %d com %d ataques e %d pontos no jogo abortado", player.Id, player.Attacks, player.Points)
+		}
+	}
+
+	// Um jogo que progride termina normalmente com o watchdog ligado
+	game = newTestRunner(t, 50, 3, 20, 2, 8)
+	game.SetOutput(io.Discard)
+	game.SetWatchdog(5 * time.Second)
+	for _, strategy := range entity.Strategies() {
+		if result := game.Run(context.Background(), strategy); result.Stalled != nil || result.Interrupted {
+			t.Errorf("%s: o watchdog abortou um jogo que progride", strategy.Name)
+		}
+	}
+}
+
+func TestRunConsistencyCheck(t *testing.T) {
+	game := newTestRunner(t, 300, 3, 10, 4, 21)
+	game.SetOutput(io.Discard)
+	game.SetConsistencyCheck(time.Millisecond)
+	for _, strategy := range entity.Strategies() {
+		t.Run(strategy.Name, func(t *testing.T) {
+			result := game.Run(context.Background(), strategy)
+			checkReplicas(t, result, 4)
+
+			// As divergências do fim do jogo são exatamente os blocos com
+			// saúdes diferentes no resultado
+			want := make(map[int]bool)
+			for _, replica := range result.Replicas[1:] {
+				for i, row := range replica.Health {
+					for j, health := range row {
+						if health != result.Replicas[0].Health[i][j] {
+							want[i*3+j+1] = true
+						}
+					}
+				}
+			}
+			got := make(map[int]bool)
+			for _, divergence := range result.Divergences {
+				if divergence.Final {
+					got[divergence.Block] = true
+				}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("blocos divergentes %v, esperado %v", got, want)
+			}
+			if len(result.Replicas) == 1 && len(result.Divergences) > 0 {
+				t.Errorf("divergências com uma única matriz: %+v", result.Divergences)
+			}
+		})
+	}
+}
+
+func TestRunLinearizability(t *testing.T) {
+	game := newTestRunner(t, 200, 3, 10, 4, 13)
+	game.SetOutput(io.Discard)
+	game.SetLinearizability(true)
+	for _, strategy := range entity.Strategies() {
+		t.Run(strategy.Name, func(t *testing.T) {
+			result := game.Run(context.Background(), strategy)
+			report := result.Linearizability
+			if report == nil {
+				t.Fatal("o resultado não tem a verificação de linearizabilidade")
+			}
+			if report.Operations != 4*200 {
+				t.Errorf("Operations = %d, esperado %d", report.Operations, 4*200)
+			}
+
+			// Só a troca de mensagens, com uma réplica por player, pode perder
+			// atualizações. Cada violação traz apenas ataques do seu bloco
+			if strategy.Name != entity.MessageStrategy.Name && len(report.Violations) > 0 {
+				t.Errorf("violações numa matriz compartilhada: %+v", report.Violations)
+			}
+			for _, violation := range report.Violations {
+				if len(violation.Operations) == 0 {
+					t.Errorf("violação do bloco %d sem ataques", violation.Block)
+				}
+				for _, op := range violation.Operations {
+					if op.Block != violation.Block {
+						t.Errorf("ataque ao bloco %d na violação do bloco %d", op.Block, violation.Block)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRunChaos(t *testing.T) {
+	game := newTestRunner(t, 100, 3, 20, 3, 17)
+	game.SetOutput(io.Discard)
+	game.SetChaos(tools.ChaosConfig{Reorder: 0.5, Panic: 0.1, Seed: 4})
+	for _, strategy := range entity.Strategies() {
+		t.Run(strategy.Name, func(t *testing.T) {
+			result := game.Run(context.Background(), strategy)
+			if result.Chaos == nil {
+				t.Fatal("o resultado não tem o relatório do modo caos")
+			}
+			if result.Linearizability != nil {
+				t.Error("o modo caos salvou a verificação de linearizabilidade sem ela ser pedida")
+			}
+
+			// Os pânicos são recuperados, e só quem entrou em pânico deixou de
+			// contar o ataque
+			attacks := 0
+			for _, player := range result.Players {
+				attacks += player.Attacks
+			}
+			if panics := result.Chaos.Faults.Panics; panics == 0 || int64(attacks)+panics != 3*100 {
+				t.Errorf("%d ataques e %d pânicos, esperado %d no total", attacks, panics, 3*100)
+			}
+
+			// Todas as estratégias liberam o lock num defer. Fora a troca de
+			// mensagens, nenhuma invariante é quebrada
+			for _, invariant := range result.Chaos.Invariants {
+				if !invariant.Held && (invariant.Name == "locks-released" || strategy.Name != entity.MessageStrategy.Name) {
+					t.Errorf("invariante %s violada: %s", invariant.Name, invariant.Detail)
+				}
+			}
+		})
+	}
+}
+
+func TestSaveResults(t *testing.T) {
+	game := newTestRunner(t, 20, 2, 50, 2, 3)
+	results := game.Results([]RunResult{game.Run(context.Background(), entity.MutexStrategy)})
+
+	filename := filepath.Join(t.TempDir(), "results.json")
+	if err := SaveResults(results, filename); err != nil {
+		t.Fatal(err)
+	}
+	if results.Version != ResultsVersion || !results.Config.VirtualClock || *results.Config.Seed != 3 {
+		t.Errorf("configuração inesperada: %+v", results.Config)
+	}
+	if !tools.FileExists(filename) {
+		t.Error("SaveResults() não criou o arquivo")
+	}
+}
+
+func TestLoadResults(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    Config
+		wantErr bool
+	}{
+		{
+			name: "versão 1",
+			data: `{"version": 1, "config": {"num_attacks": 10, "matrix_size": 3}}`,
+			want: Config{NumAttacks: 10, MatrixSize: 3, Width: 3, Height: 3},
+		},
+		{
+			name: "versão 2",
+			data: `{"version": 2, "config": {"num_attacks": 10, "width": 4, "height": 2}}`,
+			want: Config{NumAttacks: 10, Width: 4, Height: 2},
+		},
+		{name: "versão futura", data: `{"version": 3, "config": {}}`, wantErr: true},
+		{name: "sem versão", data: `{"config": {}}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "results.json")
+			if err := os.WriteFile(filename, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			results, err := LoadResults(filename)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadResults() erro = %v, esperado erro: %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if results.Version != ResultsVersion || !reflect.DeepEqual(results.Config, tt.want) {
+				t.Errorf("LoadResults() = versão %d, %+v, esperado %+v", results.Version, results.Config, tt.want)
+			}
+		})
+	}
+}
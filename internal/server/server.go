@@ -0,0 +1,382 @@
+package server
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/brnocorreia/concurrency/internal/config/logger"
+	"github.com/brnocorreia/concurrency/internal/entity"
+	"github.com/brnocorreia/concurrency/internal/metrics"
+	"github.com/brnocorreia/concurrency/internal/runner"
+	"github.com/brnocorreia/concurrency/internal/tools"
+	"go.uber.org/zap"
+)
+
+//go:embed index.html
+var indexPage []byte
+
+// Limites de cada execução pedida ao servidor. Qualquer cliente pode pedir
+// um jogo, e sem eles um único POST ocuparia a memória e as goroutines do
+// processo com o tabuleiro, as sequências e os players. Vários POSTs são
+// limitados por maxActiveRuns
+const (
+	MaxSize    = 64
+	MaxAttacks = 100_000
+	MaxPlayers = 64
+)
+
+// Mensagens guardadas de cada execução e execuções terminadas mantidas pelo
+// servidor, as mais antigas são descartadas, e execuções em andamento ao
+// mesmo tempo
+const (
+	maxMessages   = 1 << 14
+	maxRuns       = 100
+	maxActiveRuns = 4
+)
+
+// Parâmetros de uma execução pedida pelo POST /runs. Campos ausentes usam os
+// mesmos valores padrão do comando run
+type RunRequest struct {
+	Mode    string `json:"mode"`
+	Size    int    `json:"size"`
+	Attacks int    `json:"attacks"`
+	Power   int    `json:"power"`
+	Players int    `json:"players"`
+	// Semente das sequências de ataque, aleatória se ausente
+	Seed  *int64 `json:"seed,omitempty"`
+	Clock string `json:"clock"`
+}
+
+func (r *RunRequest) setDefaults() {
+	if r.Mode == "" {
+		r.Mode = entity.MutexStrategy.Name
+	}
+	if r.Size == 0 {
+		r.Size = 8
+	}
+	if r.Attacks == 0 {
+		r.Attacks = 256
+	}
+	if r.Power == 0 {
+		r.Power = 30
+	}
+	if r.Players == 0 {
+		r.Players = 2
+	}
+	if r.Seed == nil {
+		seed := tools.NewSeed()
+		r.Seed = &seed
+	}
+	if r.Clock == "" {
+		r.Clock = tools.ClockReal
+	}
+}
+
+func (r *RunRequest) validate() error {
+	if _, ok := entity.LookupStrategy(r.Mode); !ok {
+		return fmt.Errorf("modo inválido: %q", r.Mode)
+	}
+	if r.Size < 1 || r.Attacks < 1 || r.Power < 1 || r.Players < 1 {
+		return errors.New("size, attacks, power e players devem ser maiores que zero")
+	}
+	if r.Size > MaxSize || r.Attacks > MaxAttacks || r.Players > MaxPlayers {
+		return fmt.Errorf("size, attacks e players devem ser no máximo %d, %d e %d", MaxSize, MaxAttacks, MaxPlayers)
+	}
+	if _, err := tools.NewClock(r.Clock); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Mensagem enviada pelo stream de eventos de uma execução. Além dos eventos
+// dos blocos, o stream começa com "start" e termina com "end"
+type message struct {
+	Kind   string `json:"kind"`
+	Player int    `json:"player,omitempty"`
+	Block  int    `json:"block,omitempty"`
+	Health int    `json:"health"`
+	// Presentes apenas no "start"
+	Label  string `json:"label,omitempty"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+	// Presente apenas no "end"
+	Result *runner.RunResult `json:"result,omitempty"`
+}
+
+// Execução iniciada pelo servidor. Guarda o "start" e as últimas mensagens do
+// stream para que quem se inscrever depois do início receba a execução. As
+// mensagens são numeradas a partir do "start", que é a de número 0
+type run struct {
+	Id      int        `json:"id"`
+	Request RunRequest `json:"request"`
+
+	mutex sync.Mutex
+	start []byte
+	// Últimas maxMessages mensagens depois do "start" e o número da primeira
+	messages [][]byte
+	first    int
+	done     bool
+	result   *runner.RunResult
+	// Fechado e substituído a cada mensagem nova, acordando os inscritos
+	notify chan struct{}
+}
+
+func newRun(id int, request RunRequest) *run {
+	return &run{Id: id, Request: request, first: 1, notify: make(chan struct{})}
+}
+
+func (r *run) publish(msg message, done bool) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		logger.Error("Erro ao codificar o evento", err)
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if msg.Kind == "start" {
+		r.start = data
+	} else {
+		// Descarta um quarto das mensagens de uma vez. A lista nova não
+		// divide memória com a antiga, que pode estar sendo lida pelo stream
+		if len(r.messages) == maxMessages {
+			drop := maxMessages / 4
+			r.messages = slices.Clone(r.messages[drop:])
+			r.first += drop
+		}
+		r.messages = append(r.messages, data)
+	}
+	r.done = r.done || done
+	close(r.notify)
+	r.notify = make(chan struct{})
+}
+
+// Os eventos dos blocos chegam por aqui
+func (r *run) Emit(event entity.Event) {
+	r.publish(message{
+		Kind:   string(event.Kind),
+		Player: event.Player,
+		Block:  event.Block,
+		Health: event.Health,
+	}, false)
+}
+
+func (r *run) finished() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.done
+}
+
+// Retorna as mensagens a partir do número from, o número da mensagem
+// seguinte, o canal que avisa da próxima e se a execução terminou. Quem ficou
+// para trás pula as mensagens que já foram descartadas
+func (r *run) since(from int) ([][]byte, int, <-chan struct{}, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.start == nil {
+		return nil, from, r.notify, r.done
+	}
+	var messages [][]byte
+	if from == 0 {
+		messages = append(messages, r.start)
+	}
+	from = max(from, r.first)
+	messages = append(messages, r.messages[from-r.first:]...)
+	return messages, r.first + len(r.messages), r.notify, r.done
+}
+
+// Server executa jogos pedidos por HTTP e transmite os seus eventos por
+// server-sent events para a página embutida
+type Server struct {
+	// Contexto das execuções: quando é cancelado, todos os jogos param
+	ctx     context.Context
+	mux     *http.ServeMux
+	metrics *metrics.Registry
+
+	mutex sync.Mutex
+	runs  map[int]*run
+	// Id da última execução criada
+	last int
+}
+
+func New(ctx context.Context) *Server {
+	s := &Server{ctx: ctx, mux: http.NewServeMux(), metrics: metrics.NewRegistry(), runs: make(map[int]*run)}
+	s.mux.HandleFunc("GET /{$}", s.handleIndex)
+	s.mux.Handle("GET /metrics", s.metrics)
+	s.mux.HandleFunc("GET /modes", s.handleModes)
+	s.mux.HandleFunc("POST /runs", s.handleCreateRun)
+	s.mux.HandleFunc("GET /runs/{id}", s.handleGetRun)
+	s.mux.HandleFunc("GET /runs/{id}/events", s.handleEvents)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(indexPage)
+}
+
+func (s *Server) handleModes(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, entity.StrategyNames())
+}
+
+func (s *Server) handleCreateRun(w http.ResponseWriter, r *http.Request) {
+	var request RunRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, fmt.Sprintf("JSON inválido: %v", err), http.StatusBadRequest)
+		return
+	}
+	request.setDefaults()
+	if err := request.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mutex.Lock()
+	if s.active() >= maxActiveRuns {
+		s.mutex.Unlock()
+		http.Error(w, fmt.Sprintf("já existem %d execuções em andamento", maxActiveRuns), http.StatusTooManyRequests)
+		return
+	}
+	s.last++
+	current := newRun(s.last, request)
+	s.runs[current.Id] = current
+	s.prune()
+	s.mutex.Unlock()
+
+	go s.execute(current)
+
+	w.Header().Set("Location", fmt.Sprintf("/runs/%d", current.Id))
+	writeJSON(w, http.StatusCreated, current)
+}
+
+// Executa o jogo de uma execução, publicando os eventos dos blocos
+func (s *Server) execute(current *run) {
+	request := current.Request
+	strategy, _ := entity.LookupStrategy(request.Mode)
+	clock, _ := tools.NewClock(request.Clock)
+
+	game := runner.NewRunner(request.Attacks, request.Size, request.Power, request.Players, clock)
+	game.SetSequences(tools.NewSequences(tools.NewShape(request.Size, request.Size), request.Attacks, request.Players, *request.Seed, tools.Uniform))
+	game.SetOutput(io.Discard)
+	game.SetEvents(entity.Sinks{current, s.metrics.Sink(request.Mode)})
+
+	logger.Info("Iniciando a execução pedida pelo servidor", zap.Int("run", current.Id), zap.String("mode", request.Mode))
+	current.publish(message{Kind: "start", Label: strategy.Label, Width: request.Size, Height: request.Size}, false)
+	result := game.Run(s.ctx, strategy)
+
+	current.mutex.Lock()
+	current.result = &result
+	current.mutex.Unlock()
+	current.publish(message{Kind: "end", Result: &result}, true)
+}
+
+// Esquece as execuções terminadas que ficaram mais de maxRuns para trás. As
+// que ainda estão rodando continuam acessíveis. Quem chama segura o mutex
+func (s *Server) prune() {
+	for id, current := range s.runs {
+		if id <= s.last-maxRuns && current.finished() {
+			delete(s.runs, id)
+		}
+	}
+}
+
+// Execuções que ainda não terminaram. Quem chama segura o mutex
+func (s *Server) active() int {
+	active := 0
+	for _, current := range s.runs {
+		if !current.finished() {
+			active++
+		}
+	}
+	return active
+}
+
+func (s *Server) lookupRun(w http.ResponseWriter, r *http.Request) (*run, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	current, ok := s.runs[id]
+	if err != nil || !ok {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	return current, true
+}
+
+func (s *Server) handleGetRun(w http.ResponseWriter, r *http.Request) {
+	current, ok := s.lookupRun(w, r)
+	if !ok {
+		return
+	}
+
+	current.mutex.Lock()
+	defer current.mutex.Unlock()
+	writeJSON(w, http.StatusOK, struct {
+		*run
+		Done   bool              `json:"done"`
+		Result *runner.RunResult `json:"result,omitempty"`
+	}{current, current.done, current.result})
+}
+
+// Transmite as mensagens guardadas da execução e segue transmitindo até o
+// jogo terminar ou o cliente desconectar
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	current, ok := s.lookupRun(w, r)
+	if !ok {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming não suportado", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	next := 0
+	for {
+		messages, following, notify, done := current.since(next)
+		for _, data := range messages {
+			fmt.Fprintf(w, "data: %s\n\n", data)
+		}
+		next = following
+		flusher.Flush()
+		if done {
+			return
+		}
+
+		select {
+		case <-notify:
+			// Junta as mensagens que chegarem em rajada num único envio
+			time.Sleep(10 * time.Millisecond)
+		case <-r.Context().Done():
+			return
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,251 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/brnocorreia/concurrency/internal/entity"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	ts := httptest.NewServer(New(ctx))
+	t.Cleanup(func() {
+		cancel()
+		ts.Close()
+	})
+	return ts
+}
+
+func postRun(t *testing.T, ts *httptest.Server, body string) *http.Response {
+	t.Helper()
+	resp, err := http.Post(ts.URL+"/runs", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// Lê o stream de eventos até o fim do jogo
+func readEvents(t *testing.T, url string) []message {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", got)
+	}
+
+	var messages []message
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var msg message
+		if err := json.Unmarshal([]byte(data), &msg); err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
+func TestCreateRun(t *testing.T) {
+	ts := newTestServer(t)
+
+	resp := postRun(t, ts, `{"mode":"mutex","size":3,"attacks":40,"power":50,"players":2,"seed":1,"clock":"virtual"}`)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("POST /runs = %d", resp.StatusCode)
+	}
+	var created run
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	if created.Id != 1 || resp.Header.Get("Location") != "/runs/1" {
+		t.Fatalf("execução criada com id %d em %q", created.Id, resp.Header.Get("Location"))
+	}
+
+	messages := readEvents(t, ts.URL+"/runs/1/events")
+	if len(messages) < 2 || messages[0].Kind != "start" || messages[len(messages)-1].Kind != "end" {
+		t.Fatalf("o stream deve começar com start e terminar com end: %d mensagens", len(messages))
+	}
+	if start := messages[0]; start.Width != 3 || start.Height != 3 || start.Label != "MUTEX" {
+		t.Errorf("start inesperado: %+v", start)
+	}
+
+	// Os pontos do resultado são as destruições transmitidas
+	kills := make(map[int]int)
+	for _, msg := range messages {
+		if msg.Kind == "kill" {
+			kills[msg.Player]++
+		}
+	}
+	result := messages[len(messages)-1].Result
+	if result == nil || result.Interrupted {
+		t.Fatalf("resultado inesperado: %+v", result)
+	}
+	for _, player := range result.Players {
+		if player.Points != kills[player.Id] {
+			t.Errorf("player %d tem %d pontos e %d destruições transmitidas", player.Id, player.Points, kills[player.Id])
+		}
+	}
+
+	// Quem se inscreve depois do fim recebe a execução inteira
+	if again := readEvents(t, ts.URL+"/runs/1/events"); len(again) != len(messages) {
+		t.Errorf("segunda leitura com %d mensagens, esperado %d", len(again), len(messages))
+	}
+
+	get, err := http.Get(ts.URL + "/runs/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer get.Body.Close()
+	var status struct {
+		Done bool `json:"done"`
+	}
+	if err := json.NewDecoder(get.Body).Decode(&status); err != nil || !status.Done {
+		t.Errorf("GET /runs/1 = done %v, erro %v", status.Done, err)
+	}
+}
+
+func TestCreateRunInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "JSON inválido", body: `{`},
+		{name: "modo desconhecido", body: `{"mode":"lunar"}`},
+		{name: "todos os modos", body: `{"mode":"all"}`},
+		{name: "tamanho negativo", body: `{"size":-1}`},
+		{name: "relógio desconhecido", body: `{"clock":"lunar"}`},
+		{name: "matriz grande demais", body: `{"size":65}`},
+		{name: "ataques demais", body: `{"attacks":100001}`},
+		{name: "players demais", body: `{"players":65}`},
+		{name: "campo desconhecido", body: `{"speed":2}`},
+	}
+
+	ts := newTestServer(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if resp := postRun(t, ts, tt.body); resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("POST /runs = %d, esperado %d", resp.StatusCode, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+// Um stream longo guarda só as últimas mensagens, sempre depois do start
+func TestRunHistory(t *testing.T) {
+	current := newRun(1, RunRequest{})
+	if messages, next, _, _ := current.since(0); len(messages) != 0 || next != 0 {
+		t.Fatalf("since(0) antes do start = %d mensagens, próxima %d", len(messages), next)
+	}
+	current.publish(message{Kind: "start", Label: "MUTEX"}, false)
+	for i := range maxMessages + 10 {
+		current.Emit(entity.Event{Kind: entity.EventHit, Block: i + 1})
+	}
+	current.publish(message{Kind: "end"}, true)
+
+	messages, next, _, done := current.since(0)
+	if !done || next != maxMessages+12 {
+		t.Fatalf("since(0) = próxima %d, terminada %v", next, done)
+	}
+	if !strings.Contains(string(messages[0]), `"start"`) || !strings.Contains(string(messages[len(messages)-1]), `"end"`) {
+		t.Errorf("o stream não começa com start e termina com end")
+	}
+	if len(messages) > maxMessages+1 {
+		t.Errorf("%d mensagens guardadas, o limite é %d", len(messages), maxMessages+1)
+	}
+	// Quem estava no meio das mensagens descartadas continua da mais antiga
+	if late, _, _, _ := current.since(1); len(late) != len(messages)-1 {
+		t.Errorf("since(1) = %d mensagens, esperado %d", len(late), len(messages)-1)
+	}
+}
+
+// Só as execuções terminadas mais antigas que maxRuns são esquecidas
+func TestPruneRuns(t *testing.T) {
+	s := New(context.Background())
+	for id := 1; id <= maxRuns+2; id++ {
+		current := newRun(id, RunRequest{})
+		current.done = id != 1
+		s.runs[id] = current
+		s.last = id
+	}
+	s.prune()
+	if _, ok := s.runs[1]; !ok {
+		t.Error("a execução 1, ainda rodando, foi esquecida")
+	}
+	if _, ok := s.runs[2]; ok {
+		t.Error("a execução 2, terminada, continua guardada")
+	}
+	if len(s.runs) != maxRuns+1 {
+		t.Errorf("%d execuções guardadas, esperado %d", len(s.runs), maxRuns+1)
+	}
+}
+
+// Com maxActiveRuns execuções em andamento, novas execuções são recusadas até
+// alguma terminar
+func TestCreateRunActiveLimit(t *testing.T) {
+	s := New(context.Background())
+	for id := 1; id <= maxActiveRuns; id++ {
+		s.runs[id] = newRun(id, RunRequest{})
+		s.last = id
+	}
+
+	post := func() int {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/runs", strings.NewReader(`{"size": 3, "attacks": 1, "players": 1}`))
+		s.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := post(); code != http.StatusTooManyRequests {
+		t.Fatalf("status %d, esperado %d", code, http.StatusTooManyRequests)
+	}
+	s.runs[1].publish(message{Kind: "end"}, true)
+	if code := post(); code != http.StatusCreated {
+		t.Errorf("status %d, esperado %d", code, http.StatusCreated)
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	ts := newTestServer(t)
+
+	tests := []struct {
+		path        string
+		status      int
+		contentType string
+	}{
+		{path: "/", status: http.StatusOK, contentType: "text/html; charset=utf-8"},
+		{path: "/modes", status: http.StatusOK, contentType: "application/json"},
+		{path: "/metrics", status: http.StatusOK, contentType: "text/plain; version=0.0.4; charset=utf-8"},
+		{path: "/runs/1", status: http.StatusNotFound},
+		{path: "/runs/abc/events", status: http.StatusNotFound},
+		{path: "/missing", status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		resp, err := http.Get(ts.URL + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("GET %s = %d, esperado %d", tt.path, resp.StatusCode, tt.status)
+		}
+		if tt.contentType != "" && resp.Header.Get("Content-Type") != tt.contentType {
+			t.Errorf("GET %s com Content-Type %q", tt.path, resp.Header.Get("Content-Type"))
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Nomes das falhas aceitas por ParseChaos
+const (
+	ChaosDelay   = "delay"
+	ChaosReorder = "reorder"
+	ChaosPanic   = "panic"
+	ChaosSeed    = "seed"
+)
+
+// Valor do pânico injetado dentro do Hit. Só ele é recuperado pelo jogo,
+// qualquer outro pânico continua sendo um erro de verdade
+var ErrChaosPanic = errors.New("pânico injetado pelo modo caos")
+
+// Falhas que o modo caos injeta nas primitivas de sincronização. Delay é o
+// atraso máximo antes de cada aquisição e de cada mensagem entregue, Reorder
+// a probabilidade de uma atualização ser entregue depois da seguinte e Panic
+// a probabilidade de um ataque entrar em pânico dentro do Hit
+type ChaosConfig struct {
+	Delay   time.Duration
+	Reorder float64
+	Panic   float64
+	// Semente do sorteio das falhas, zero sorteia uma nova a cada jogo
+	Seed int64
+}
+
+func ChaosNames() []string {
+	return []string{ChaosDelay, ChaosReorder, ChaosPanic, ChaosSeed}
+}
+
+// Lê as falhas no formato nome:valor separados por vírgula, como
+// delay:2ms,reorder:0.2,panic:0.01. Vazio desliga o modo caos
+func ParseChaos(spec string) (ChaosConfig, error) {
+	var config ChaosConfig
+	if strings.TrimSpace(spec) == "" {
+		return config, nil
+	}
+	for _, item := range strings.Split(spec, ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(item), ":")
+		if !found {
+			return ChaosConfig{}, fmt.Errorf("falha sem valor %q, use nome:valor", item)
+		}
+		var err error
+		switch name {
+		case ChaosDelay:
+			config.Delay, err = time.ParseDuration(value)
+			if err == nil && config.Delay < 0 {
+				err = errors.New("negativo")
+			}
+		case ChaosReorder:
+			config.Reorder, err = parseProbability(value)
+		case ChaosPanic:
+			config.Panic, err = parseProbability(value)
+		case ChaosSeed:
+			config.Seed, err = strconv.ParseInt(value, 10, 64)
+		default:
+			return ChaosConfig{}, fmt.Errorf("falha desconhecida %q, use %s", name, strings.Join(ChaosNames(), ", "))
+		}
+		if err != nil {
+			return ChaosConfig{}, fmt.Errorf("valor inválido %q para a falha %s", value, name)
+		}
+	}
+	return config, nil
+}
+
+func parseProbability(value string) (float64, error) {
+	p, err := strconv.ParseFloat(value, 64)
+	if err != nil || p < 0 || p > 1 {
+		return 0, errors.New("a probabilidade precisa estar entre 0 e 1")
+	}
+	return p, nil
+}
+
+// Indica se alguma falha é injetada
+func (c ChaosConfig) Enabled() bool {
+	return c.Delay > 0 || c.Reorder > 0 || c.Panic > 0
+}
+
+func (c ChaosConfig) String() string {
+	var items []string
+	if c.Delay > 0 {
+		items = append(items, fmt.Sprintf("%s:%v", ChaosDelay, c.Delay))
+	}
+	if c.Reorder > 0 {
+		items = append(items, fmt.Sprintf("%s:%v", ChaosReorder, c.Reorder))
+	}
+	if c.Panic > 0 {
+		items = append(items, fmt.Sprintf("%s:%v", ChaosPanic, c.Panic))
+	}
+	if c.Seed != 0 {
+		items = append(items, fmt.Sprintf("%s:%d", ChaosSeed, c.Seed))
+	}
+	return strings.Join(items, ",")
+}
+
+// Quantas falhas foram injetadas durante um jogo
+type ChaosFaults struct {
+	Delays   int64 `json:"delays"`
+	Reorders int64 `json:"reorders"`
+	Panics   int64 `json:"panics"`
+}
+
+// Injeta as falhas de um jogo. Todos os métodos aceitam um *Chaos nil, que
+// não injeta nada, então as estratégias não precisam saber se o modo está ligado
+type Chaos struct {
+	config   ChaosConfig
+	mutex    sync.Mutex
+	rng      *rand.Rand
+	delays   atomic.Int64
+	reorders atomic.Int64
+	panics   atomic.Int64
+}
+
+func NewChaos(config ChaosConfig) *Chaos {
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Chaos{config: config, rng: rand.New(rand.NewSource(seed))}
+}
+
+func (c *Chaos) Config() ChaosConfig {
+	if c == nil {
+		return ChaosConfig{}
+	}
+	return c.config
+}
+
+func (c *Chaos) Faults() ChaosFaults {
+	if c == nil {
+		return ChaosFaults{}
+	}
+	return ChaosFaults{Delays: c.delays.Load(), Reorders: c.reorders.Load(), Panics: c.panics.Load()}
+}
+
+// Sorteia um número em [0, 1). O rand.Rand não é seguro para uso concorrente
+func (c *Chaos) float() float64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.rng.Float64()
+}
+
+// Espera um tempo aleatório de até Delay. O atraso é real mesmo com o relógio
+// virtual, porque o objetivo é mudar a ordem em que as goroutines rodam
+func (c *Chaos) Delay() {
+	if c == nil || c.config.Delay <= 0 {
+		return
+	}
+	c.delays.Add(1)
+	time.Sleep(time.Duration(c.float() * float64(c.config.Delay)))
+}
+
+// Entra em pânico com ErrChaosPanic na probabilidade de Panic
+func (c *Chaos) MaybePanic() {
+	if c == nil || c.config.Panic <= 0 || c.float() >= c.config.Panic {
+		return
+	}
+	c.panics.Add(1)
+	panic(ErrChaosPanic)
+}
+
+func (c *Chaos) reorder() bool {
+	if c == nil || c.config.Reorder <= 0 || c.float() >= c.config.Reorder {
+		return false
+	}
+	c.reorders.Add(1)
+	return true
+}
+
+// Atrasa cada aquisição de um lock uma única vez. O jogo tenta o lock sem
+// bloquear e, se ele estiver ocupado, chama o Lock: a tentativa que falhou já
+// atrasou a aquisição e deixa um crédito, que o Lock seguinte gasta em vez de
+// atrasar de novo. O crédito não é de uma goroutine específica, mas cada
+// tentativa que falha é seguida de um Lock, então os atrasos somam sempre um
+// por aquisição
+type acquireDelay struct {
+	chaos   *Chaos
+	credits atomic.Int64
+}
+
+func (d *acquireDelay) try(acquire func() bool) bool {
+	d.chaos.Delay()
+	if acquire() {
+		return true
+	}
+	d.credits.Add(1)
+	return false
+}
+
+func (d *acquireDelay) lock() {
+	for {
+		credits := d.credits.Load()
+		if credits == 0 {
+			d.chaos.Delay()
+			return
+		}
+		if d.credits.CompareAndSwap(credits, credits-1) {
+			return
+		}
+	}
+}
+
+type chaosLocker struct {
+	Locker
+	delay *acquireDelay
+}
+
+func (l chaosLocker) Lock() {
+	l.delay.lock()
+	l.Locker.Lock()
+}
+
+func (l chaosLocker) TryLock() bool {
+	return l.delay.try(l.Locker.TryLock)
+}
+
+// Atrasa as aquisições do lock
+func (c *Chaos) Locker(l Locker) Locker {
+	if c == nil {
+		return l
+	}
+	return chaosLocker{Locker: l, delay: &acquireDelay{chaos: c}}
+}
+
+type chaosAcquirer struct {
+	Acquirer
+	delay *acquireDelay
+}
+
+func (s chaosAcquirer) Acquire() {
+	s.delay.lock()
+	s.Acquirer.Acquire()
+}
+
+func (s chaosAcquirer) TryAcquire() bool {
+	return s.delay.try(s.Acquirer.TryAcquire)
+}
+
+// Atrasa as aquisições do semáforo
+func (c *Chaos) Acquirer(s Acquirer) Acquirer {
+	if c == nil {
+		return s
+	}
+	return chaosAcquirer{Acquirer: s, delay: &acquireDelay{chaos: c}}
+}
+
+type chaosPriorityLocker struct {
+	PriorityLocker
+	delay *acquireDelay
+}
+
+func (m chaosPriorityLocker) Lock(highPriority bool) {
+	m.delay.lock()
+	m.PriorityLocker.Lock(highPriority)
+}
+
+func (m chaosPriorityLocker) TryLock(highPriority bool) bool {
+	return m.delay.try(func() bool { return m.PriorityLocker.TryLock(highPriority) })
+}
+
+// Atrasa as aquisições do lock de prioridade
+func (c *Chaos) PriorityLocker(m PriorityLocker) PriorityLocker {
+	if c == nil {
+		return m
+	}
+	return chaosPriorityLocker{PriorityLocker: m, delay: &acquireDelay{chaos: c}}
+}
+
+// Repassa as mensagens de in com um atraso antes de cada uma. Com reorder,
+// uma mensagem sorteada é segurada e entregue logo depois da seguinte. Nenhuma
+// mensagem se perde: as seguradas são entregues quando in é fechado
+func ChaosChannel[T any](c *Chaos, in <-chan T, reorder bool) <-chan T {
+	if c == nil {
+		return in
+	}
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		var held []T
+		for msg := range in {
+			c.Delay()
+			if reorder && len(held) == 0 && c.reorder() {
+				held = append(held, msg)
+				continue
+			}
+			out <- msg
+			for _, late := range held {
+				out <- late
+			}
+			held = nil
+		}
+		for _, late := range held {
+			out <- late
+		}
+	}()
+	return out
+}
@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseChaos(t *testing.T) {
+	tests := []struct {
+		spec string
+		want ChaosConfig
+		str  string
+	}{
+		{spec: "", want: ChaosConfig{}, str: ""},
+		{spec: "delay:2ms", want: ChaosConfig{Delay: 2 * time.Millisecond}, str: "delay:2ms"},
+		{
+			spec: " reorder:0.25, panic:0.01 ,seed:7",
+			want: ChaosConfig{Reorder: 0.25, Panic: 0.01, Seed: 7},
+			str:  "reorder:0.25,panic:0.01,seed:7",
+		},
+		{
+			spec: "panic:1,delay:1ms,reorder:0",
+			want: ChaosConfig{Delay: time.Millisecond, Panic: 1},
+			str:  "delay:1ms,panic:1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseChaos(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseChaos() = %+v, esperado %+v", got, tt.want)
+			}
+			if got.String() != tt.str {
+				t.Errorf("String() = %q, esperado %q", got.String(), tt.str)
+			}
+			if got.Enabled() != (tt.str != "") {
+				t.Errorf("Enabled() = %v", got.Enabled())
+			}
+		})
+	}
+}
+
+func TestParseChaosErrors(t *testing.T) {
+	for _, spec := range []string{
+		"delay",
+		"delay:-1ms",
+		"delay:abc",
+		"reorder:1.5",
+		"panic:-0.1",
+		"seed:x",
+		"drop:0.1",
+	} {
+		t.Run(spec, func(t *testing.T) {
+			if _, err := ParseChaos(spec); err == nil {
+				t.Errorf("ParseChaos(%q) não retornou erro", spec)
+			}
+		})
+	}
+}
+
+func TestChaosNil(t *testing.T) {
+	var chaos *Chaos
+	chaos.Delay()
+	chaos.MaybePanic()
+	if chaos.Faults() != (ChaosFaults{}) || chaos.Config() != (ChaosConfig{}) {
+		t.Error("um Chaos nil registrou falhas")
+	}
+
+	mutex := &sync.Mutex{}
+	if chaos.Locker(mutex) != Locker(mutex) {
+		t.Error("Locker() embrulhou o mutex com o modo caos desligado")
+	}
+	in := make(chan int)
+	if ChaosChannel(chaos, in, true) != (<-chan int)(in) {
+		t.Error("ChaosChannel() embrulhou o canal com o modo caos desligado")
+	}
+}
+
+func TestChaosPanic(t *testing.T) {
+	chaos := NewChaos(ChaosConfig{Panic: 1})
+	defer func() {
+		if p := recover(); p != ErrChaosPanic {
+			t.Errorf("recover() = %v, esperado %v", p, ErrChaosPanic)
+		}
+		if faults := chaos.Faults(); faults.Panics != 1 {
+			t.Errorf("Panics = %d, esperado 1", faults.Panics)
+		}
+	}()
+	chaos.MaybePanic()
+}
+
+func TestChaosLocker(t *testing.T) {
+	chaos := NewChaos(ChaosConfig{Delay: time.Microsecond, Seed: 1})
+	mutex := chaos.Locker(&sync.Mutex{})
+	semaphore := chaos.Acquirer(NewSemaphore())
+	priority := chaos.PriorityLocker(NewPriorityMutex())
+
+	// Uma aquisição livre e uma disputada, que tenta sem bloquear e depois espera
+	mutex.Lock()
+	if mutex.TryLock() {
+		t.Error("TryLock() conseguiu um mutex travado")
+	}
+	mutex.Unlock()
+	mutex.Lock()
+	mutex.Unlock()
+
+	semaphore.Acquire()
+	if semaphore.TryAcquire() {
+		t.Error("TryAcquire() conseguiu um semáforo ocupado")
+	}
+	semaphore.Release()
+	semaphore.Acquire()
+	semaphore.Release()
+
+	priority.Lock(true)
+	if priority.TryLock(false) {
+		t.Error("TryLock() conseguiu um lock travado")
+	}
+	priority.Unlock(true)
+	priority.Lock(false)
+	priority.Unlock(false)
+	if !priority.TryLock(false) {
+		t.Error("TryLock() falhou num lock livre")
+	}
+	priority.Unlock(false)
+
+	// Cada aquisição passa por um único atraso, mesmo a disputada
+	if faults := chaos.Faults(); faults.Delays != 7 {
+		t.Errorf("Delays = %d, esperado 7", faults.Delays)
+	}
+}
+
+func TestChaosChannel(t *testing.T) {
+	tests := []struct {
+		name    string
+		reorder bool
+		want    []int
+	}{
+		{name: "só atrasos", want: []int{1, 2, 3, 4, 5}},
+		// Com probabilidade 1, cada mensagem livre é segurada até a seguinte
+		{name: "reordenado", reorder: true, want: []int{2, 1, 4, 3, 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chaos := NewChaos(ChaosConfig{Reorder: 1})
+			in := make(chan int)
+			out := ChaosChannel(chaos, in, tt.reorder)
+			go func() {
+				for i := 1; i <= 5; i++ {
+					in <- i
+				}
+				close(in)
+			}()
+
+			var got []int
+			for msg := range out {
+				got = append(got, msg)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mensagens %v, esperado %v", got, tt.want)
+			}
+		})
+	}
+}
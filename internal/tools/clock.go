@@ -0,0 +1,153 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+const (
+	ClockReal    = "real"
+	ClockVirtual = "virtual"
+)
+
+// Clock é a fonte de tempo do jogo. Os blocos usam o Sleep para simular a
+// duração de um ataque e o runner usa o Now para medir as execuções. O
+// contexto identifica a goroutine que está medindo o tempo, porque no
+// relógio virtual cada uma tem a sua própria linha do tempo
+type Clock interface {
+	Now(ctx context.Context) time.Time
+	Since(ctx context.Context, t time.Time) time.Duration
+	// Dorme pela duração informada ou até o contexto ser cancelado, caso em
+	// que retorna o erro do contexto
+	Sleep(ctx context.Context, d time.Duration) error
+	// Recebe o instante de outra goroutine, quando um lock ou uma mensagem
+	// passa dela para a goroutine do contexto. Quem recebe não pode estar
+	// antes de quem enviou
+	Sync(ctx context.Context, t time.Time)
+}
+
+// Retorna o relógio correspondente ao nome informado na linha de comando
+func NewClock(name string) (Clock, error) {
+	switch name {
+	case ClockReal:
+		return NewRealClock(), nil
+	case ClockVirtual:
+		return NewVirtualClock(time.Now()), nil
+	default:
+		return nil, fmt.Errorf("relógio inválido: %q", name)
+	}
+}
+
+// Relógio de parede, usa o pacote time diretamente
+type realClock struct{}
+
+func NewRealClock() Clock {
+	return realClock{}
+}
+
+func (realClock) Now(context.Context) time.Time {
+	return time.Now()
+}
+
+func (realClock) Since(_ context.Context, t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+func (realClock) Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// No relógio de parede as goroutines já dividem o mesmo tempo
+func (realClock) Sync(context.Context, time.Time) {}
+
+// Linha do tempo de uma goroutine no relógio virtual
+type timeline struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func (t *timeline) load() time.Time {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.now
+}
+
+// Avança a linha do tempo até o instante informado, se ela estiver atrás
+func (t *timeline) advance(to time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if to.After(t.now) {
+		t.now = to
+	}
+}
+
+func (t *timeline) add(d time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.now = t.now.Add(d)
+}
+
+type timelineKey struct{}
+
+// Dá uma linha do tempo própria, a partir de start, para a goroutine que vai
+// usar o contexto. O relógio de parede ignora a linha do tempo
+func WithTimeline(ctx context.Context, start time.Time) context.Context {
+	return context.WithValue(ctx, timelineKey{}, &timeline{now: start})
+}
+
+// Relógio simulado: o Sleep não bloqueia, apenas avança o tempo virtual da
+// goroutine. Cada goroutine anda na sua linha do tempo, criada com
+// WithTimeline, e só se sincroniza com as outras quando recebe um lock ou uma
+// mensagem delas. Assim a duração de um jogo é o caminho crítico entre os
+// players, e não a soma de todos os ataques. Os contextos sem linha do tempo
+// própria dividem a linha do relógio
+type VirtualClock struct {
+	shared timeline
+}
+
+func NewVirtualClock(start time.Time) *VirtualClock {
+	return &VirtualClock{shared: timeline{now: start}}
+}
+
+func (c *VirtualClock) timeline(ctx context.Context) *timeline {
+	if t, ok := ctx.Value(timelineKey{}).(*timeline); ok {
+		return t
+	}
+	return &c.shared
+}
+
+func (c *VirtualClock) Now(ctx context.Context) time.Time {
+	return c.timeline(ctx).load()
+}
+
+func (c *VirtualClock) Since(ctx context.Context, t time.Time) time.Duration {
+	return c.Now(ctx).Sub(t)
+}
+
+func (c *VirtualClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if d <= 0 {
+		return nil
+	}
+	c.timeline(ctx).add(d)
+	// Dá a vez para as outras goroutines, como aconteceria durante um Sleep real
+	runtime.Gosched()
+	return nil
+}
+
+func (c *VirtualClock) Sync(ctx context.Context, t time.Time) {
+	c.timeline(ctx).advance(t)
+}
@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewClock(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: ClockReal},
+		{name: ClockVirtual},
+		{name: "lunar", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock, err := NewClock(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewClock(%q) erro = %v, esperado erro: %v", tt.name, err, tt.wantErr)
+			}
+			if !tt.wantErr && clock == nil {
+				t.Fatalf("NewClock(%q) retornou um relógio nulo", tt.name)
+			}
+		})
+	}
+}
+
+func TestVirtualClockSleep(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := NewVirtualClock(start)
+
+	ctxs := make([]context.Context, 10)
+	var wg sync.WaitGroup
+	for i := range ctxs {
+		ctxs[i] = WithTimeline(context.Background(), start)
+		wg.Add(1)
+		go func(ctx context.Context) {
+			defer wg.Done()
+			if err := clock.Sleep(ctx, 100*time.Millisecond); err != nil {
+				t.Errorf("Sleep() = %v", err)
+			}
+		}(ctxs[i])
+	}
+	wg.Wait()
+
+	// Cada goroutine dorme na sua linha do tempo, em paralelo com as outras
+	for i, ctx := range ctxs {
+		if got := clock.Since(ctx, start); got != 100*time.Millisecond {
+			t.Errorf("a goroutine %d avançou %v, esperado 100ms", i, got)
+		}
+	}
+	// Sem linha do tempo própria, os Sleeps somam na linha do relógio
+	for i := 0; i < 10; i++ {
+		clock.Sleep(context.Background(), 100*time.Millisecond)
+	}
+	if got := clock.Since(context.Background(), start); got != time.Second {
+		t.Errorf("o relógio avançou %v, esperado 1s", got)
+	}
+}
+
+func TestVirtualClockSync(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := NewVirtualClock(start)
+	sender := WithTimeline(context.Background(), start)
+	receiver := WithTimeline(context.Background(), start)
+
+	clock.Sleep(sender, time.Second)
+	clock.Sleep(receiver, 300*time.Millisecond)
+	// Quem recebe o lock avança até o instante em que ele foi liberado
+	clock.Sync(receiver, clock.Now(sender))
+	if got := clock.Since(receiver, start); got != time.Second {
+		t.Errorf("Sync() levou a goroutine para %v, esperado 1s", got)
+	}
+	// Um instante anterior não volta o tempo
+	clock.Sync(receiver, start)
+	if got := clock.Since(receiver, start); got != time.Second {
+		t.Errorf("Sync() voltou a goroutine para %v", got)
+	}
+}
+
+func TestSleepCancelled(t *testing.T) {
+	clocks := map[string]Clock{
+		ClockReal:    NewRealClock(),
+		ClockVirtual: NewVirtualClock(time.Unix(0, 0)),
+	}
+
+	for name, clock := range clocks {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			start := clock.Now(ctx)
+			if err := clock.Sleep(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+				t.Errorf("Sleep() = %v, esperado %v", err, context.Canceled)
+			}
+			if got := clock.Since(ctx, start); got >= time.Hour {
+				t.Errorf("Sleep() cancelado dormiu %v", got)
+			}
+		})
+	}
+}
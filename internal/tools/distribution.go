@@ -0,0 +1,201 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Nomes das distribuições de alvos das sequências de ataque
+const (
+	DistributionUniform  = "uniform"
+	DistributionZipf     = "zipf"
+	DistributionGaussian = "gaussian"
+	DistributionHotspot  = "hotspot"
+	DistributionSweep    = "sweep"
+	DistributionShared   = "shared"
+)
+
+// Valores usados quando a distribuição não recebe o parâmetro
+const (
+	defaultZipfExponent = 1.2
+	defaultHotspots     = 3
+	// Desvio padrão dos ataques em volta de cada hotspot, em blocos
+	hotspotSpread = 1.0
+	// Tentativas de sortear uma coordenada dentro do tabuleiro antes de
+	// escolher um bloco qualquer
+	maxDraws = 100
+)
+
+// Distribuição dos alvos das sequências de ataque. Param é o parâmetro
+// opcional de cada distribuição, zero quando não é informado, e Base é a
+// distribuição sorteada uma única vez pela shared
+type Distribution struct {
+	Name  string
+	Param float64
+	Base  *Distribution
+}
+
+// Distribuição uniforme, a padrão
+var Uniform = Distribution{Name: DistributionUniform}
+
+// Nomes das distribuições disponíveis
+func DistributionNames() []string {
+	return []string{DistributionUniform, DistributionZipf, DistributionGaussian, DistributionHotspot, DistributionSweep, DistributionShared}
+}
+
+// Lê uma distribuição no formato nome[:parâmetro]. O parâmetro da shared é a
+// distribuição que todos os players compartilham, como shared:zipf:1.5
+func ParseDistribution(spec string) (Distribution, error) {
+	name, param, found := strings.Cut(strings.TrimSpace(spec), ":")
+	d := Distribution{Name: name}
+	switch name {
+	case "", DistributionUniform, DistributionSweep:
+		if name == "" {
+			d.Name = DistributionUniform
+		}
+		if found && name != DistributionSweep {
+			return Distribution{}, fmt.Errorf("a distribuição %s não tem parâmetro", d.Name)
+		}
+	case DistributionShared:
+		base := Uniform
+		if found {
+			var err error
+			if base, err = ParseDistribution(param); err != nil {
+				return Distribution{}, err
+			}
+			if base.Name == DistributionShared {
+				return Distribution{}, fmt.Errorf("a distribuição shared não pode compartilhar outra shared")
+			}
+		}
+		d.Base = &base
+		return d, nil
+	case DistributionZipf, DistributionGaussian, DistributionHotspot:
+	default:
+		return Distribution{}, fmt.Errorf("distribuição desconhecida %q, use %s", name, strings.Join(DistributionNames(), ", "))
+	}
+
+	if found {
+		value, err := strconv.ParseFloat(param, 64)
+		if err != nil || value <= 0 {
+			return Distribution{}, fmt.Errorf("parâmetro inválido %q para a distribuição %s", param, name)
+		}
+		if name == DistributionZipf && value <= 1 {
+			return Distribution{}, fmt.Errorf("o expoente da distribuição zipf precisa ser maior que 1, recebido %v", value)
+		}
+		if (name == DistributionHotspot || name == DistributionSweep) && value != math.Trunc(value) {
+			return Distribution{}, fmt.Errorf("o parâmetro da distribuição %s precisa ser inteiro, recebido %v", name, value)
+		}
+		d.Param = value
+	}
+	return d, nil
+}
+
+func (d Distribution) String() string {
+	name := d.Name
+	if name == "" {
+		name = DistributionUniform
+	}
+	switch {
+	case d.Base != nil && d.Base.Name != DistributionUniform:
+		return name + ":" + d.Base.String()
+	case d.Param != 0:
+		return name + ":" + strconv.FormatFloat(d.Param, 'g', -1, 64)
+	}
+	return name
+}
+
+// Gera a sequência de cada player. Os alvos quentes da zipf, o centro da
+// gaussian e os hotspots são sorteados uma vez e valem para todos os players
+func (d Distribution) generate(rng *rand.Rand, shape Shape, players, numAttacks int) [][][2]int {
+	sequences := make([][][2]int, players)
+	switch d.Name {
+	case "", DistributionUniform:
+		for i := range sequences {
+			sequences[i] = generateAttackSequence(rng, shape, numAttacks)
+		}
+		return sequences
+	case DistributionShared:
+		shared := d.Base.generate(rng, shape, 1, numAttacks)[0]
+		for i := range sequences {
+			sequences[i] = append([][2]int(nil), shared...)
+		}
+		return sequences
+	case DistributionSweep:
+		// Todos varrem as linhas na mesma ordem, começando Param blocos
+		// depois do player anterior
+		cells := shape.Cells()
+		for i := range sequences {
+			sequences[i] = make([][2]int, numAttacks)
+			for j := range sequences[i] {
+				sequences[i][j] = cells[(i*int(d.Param)+j)%len(cells)]
+			}
+		}
+		return sequences
+	}
+
+	draw := d.sampler(rng, shape)
+	for i := range sequences {
+		sequences[i] = make([][2]int, numAttacks)
+		for j := range sequences[i] {
+			sequences[i][j] = draw()
+		}
+	}
+	return sequences
+}
+
+// Retorna a função que sorteia um alvo das distribuições sem estado por player
+func (d Distribution) sampler(rng *rand.Rand, shape Shape) func() [2]int {
+	cells := shape.Cells()
+	switch d.Name {
+	case DistributionZipf:
+		// A ordem de popularidade dos blocos é embaralhada para que o mais
+		// atacado não seja sempre o primeiro
+		exponent := d.Param
+		if exponent == 0 {
+			exponent = defaultZipfExponent
+		}
+		ranks := rng.Perm(len(cells))
+		zipf := rand.NewZipf(rng, exponent, 1, uint64(len(cells)-1))
+		return func() [2]int {
+			return cells[ranks[zipf.Uint64()]]
+		}
+	case DistributionGaussian:
+		sigma := d.Param
+		if sigma == 0 {
+			sigma = max(1, float64(min(shape.Width, shape.Height))/4)
+		}
+		row, col := float64(shape.Height-1)/2, float64(shape.Width-1)/2
+		return func() [2]int {
+			return drawNear(rng, shape, cells, row, col, sigma)
+		}
+	default:
+		hotspots := int(d.Param)
+		if hotspots == 0 {
+			hotspots = defaultHotspots
+		}
+		centres := make([][2]int, hotspots)
+		for i := range centres {
+			centres[i] = cells[rng.Intn(len(cells))]
+		}
+		return func() [2]int {
+			centre := centres[rng.Intn(len(centres))]
+			return drawNear(rng, shape, cells, float64(centre[0]), float64(centre[1]), hotspotSpread)
+		}
+	}
+}
+
+// Sorteia um bloco em volta de (row, col) com uma normal de desvio sigma.
+// Sorteios fora do tabuleiro ou em buracos são refeitos
+func drawNear(rng *rand.Rand, shape Shape, cells [][2]int, row, col, sigma float64) [2]int {
+	for range maxDraws {
+		x := int(math.Round(row + rng.NormFloat64()*sigma))
+		y := int(math.Round(col + rng.NormFloat64()*sigma))
+		if shape.Contains(x, y) {
+			return [2]int{x, y}
+		}
+	}
+	return cells[rng.Intn(len(cells))]
+}
@@ -0,0 +1,176 @@
+package tools
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestParseDistribution(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{spec: "", want: "uniform"},
+		{spec: "uniform", want: "uniform"},
+		{spec: "zipf", want: "zipf"},
+		{spec: "zipf:1.5", want: "zipf:1.5"},
+		{spec: "gaussian:2", want: "gaussian:2"},
+		{spec: "hotspot:4", want: "hotspot:4"},
+		{spec: "sweep", want: "sweep"},
+		{spec: "sweep:3", want: "sweep:3"},
+		{spec: "shared", want: "shared"},
+		{spec: "shared:zipf:2", want: "shared:zipf:2"},
+		{spec: " hotspot ", want: "hotspot"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseDistribution(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("ParseDistribution(%q) = %s, esperado %s", tt.spec, got, tt.want)
+			}
+
+			// O nome gravado nos arquivos é lido de volta na mesma distribuição
+			again, err := ParseDistribution(got.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(again, got) {
+				t.Errorf("ParseDistribution(%q) = %+v, esperado %+v", got, again, got)
+			}
+		})
+	}
+}
+
+func TestParseDistributionErrors(t *testing.T) {
+	for _, spec := range []string{"normal", "uniform:2", "zipf:1", "zipf:x", "gaussian:0", "gaussian:-1", "hotspot:1.5", "sweep:0.5", "shared:shared", "shared:normal"} {
+		if _, err := ParseDistribution(spec); err == nil {
+			t.Errorf("ParseDistribution(%q) não retornou erro", spec)
+		}
+	}
+}
+
+// Fração dos ataques que cai no bloco mais atacado
+func topShare(sequences []SequenceFile) float64 {
+	counts := make(map[[2]int]int)
+	total, top := 0, 0
+	for _, sequence := range sequences {
+		for _, attack := range sequence.Attacks {
+			counts[attack]++
+			total++
+			top = max(top, counts[attack])
+		}
+	}
+	return float64(top) / float64(total)
+}
+
+func TestDistributions(t *testing.T) {
+	const (
+		numAttacks = 500
+		players    = 3
+		seed       = 99
+	)
+
+	ring, err := ShapeFromMask([]string{"######", "#....#", "#....#", "######"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shapes := []Shape{NewShape(10, 10), NewShape(7, 3), NewShape(1, 1), ring}
+
+	for _, spec := range DistributionNames() {
+		distribution, err := ParseDistribution(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, shape := range shapes {
+			t.Run(spec+"/"+shape.String(), func(t *testing.T) {
+				sequences := NewSequences(shape, numAttacks, players, seed, distribution)
+				for _, sequence := range sequences {
+					if sequence.Distribution != spec {
+						t.Errorf("player %d: distribuição %q, esperado %q", sequence.Player, sequence.Distribution, spec)
+					}
+					if err := ValidateSequence(sequence, "sequence.json", shape); err != nil {
+						t.Errorf("player %d: %v", sequence.Player, err)
+					}
+				}
+
+				// A mesma semente gera as mesmas sequências
+				again := NewSequences(shape, numAttacks, players, seed, distribution)
+				if !reflect.DeepEqual(sequences, again) {
+					t.Error("a mesma semente gerou sequências diferentes")
+				}
+			})
+		}
+	}
+}
+
+func TestUniformMatchesPreviousGenerator(t *testing.T) {
+	// A uniforme mantém as sequências geradas antes das distribuições
+	shape := NewShape(6, 4)
+	sequences := NewSequences(shape, 40, 2, 7, Uniform)
+	rng := rand.New(rand.NewSource(7))
+	for _, sequence := range sequences {
+		if want := generateAttackSequence(rng, shape, 40); !reflect.DeepEqual(sequence.Attacks, want) {
+			t.Errorf("player %d: a sequência uniforme mudou", sequence.Player)
+		}
+	}
+}
+
+func TestSkewedDistributions(t *testing.T) {
+	const (
+		numAttacks = 2000
+		players    = 2
+		seed       = 3
+	)
+	shape := NewShape(20, 20)
+	uniform := topShare(NewSequences(shape, numAttacks, players, seed, Uniform))
+
+	for _, spec := range []string{"zipf", "zipf:2", "gaussian:1", "hotspot:1"} {
+		distribution, err := ParseDistribution(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// O bloco mais atacado recebe bem mais ataques que na uniforme
+		if got := topShare(NewSequences(shape, numAttacks, players, seed, distribution)); got < 4*uniform {
+			t.Errorf("%s: o bloco mais atacado recebeu %.3f dos ataques, esperado ao menos %.3f", spec, got, 4*uniform)
+		}
+	}
+}
+
+func TestSharedAndSweep(t *testing.T) {
+	shape := NewShape(5, 5)
+
+	shared, err := ParseDistribution("shared:zipf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sequences := NewSequences(shape, 100, 4, 1, shared)
+	for _, sequence := range sequences[1:] {
+		if !reflect.DeepEqual(sequence.Attacks, sequences[0].Attacks) {
+			t.Errorf("player %d não recebeu a sequência compartilhada", sequence.Player)
+		}
+	}
+
+	sweep, err := ParseDistribution("sweep:2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		player int
+		want   [][2]int
+	}{
+		{player: 1, want: [][2]int{{0, 0}, {0, 1}, {0, 2}, {0, 3}, {0, 4}, {1, 0}}},
+		{player: 2, want: [][2]int{{0, 2}, {0, 3}, {0, 4}, {1, 0}, {1, 1}, {1, 2}}},
+		{player: 3, want: [][2]int{{0, 4}, {1, 0}, {1, 1}, {1, 2}, {1, 3}, {1, 4}}},
+	}
+	sequences = NewSequences(shape, 6, 3, 1, sweep)
+	for _, tt := range tests {
+		if got := sequences[tt.player-1].Attacks; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("player %d: sweep = %v, esperado %v", tt.player, got, tt.want)
+		}
+	}
+}
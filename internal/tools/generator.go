@@ -1,9 +1,11 @@
 package tools
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
 	"math/rand"
 
@@ -11,20 +13,58 @@ import (
 	"go.uber.org/zap"
 )
 
-func generateAttackSequence(size, numAttacks int) [][2]int {
+// Versão do formato dos arquivos de sequência. Arquivos antigos, que contêm
+// apenas a lista de coordenadas, são lidos como versão 0. A versão 1 só
+// registra o lado de uma matriz quadrada em Size
+const SequenceVersion = 2
+
+// Conteúdo de um arquivo de sequência de ataques
+type SequenceFile struct {
+	Version int   `json:"version"`
+	Seed    int64 `json:"seed"`
+	// Lado da matriz quando ela é quadrada, mantido para leitores antigos
+	Size   int `json:"size,omitempty"`
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+	// Distribuição dos alvos, ausente nos arquivos gerados antes dela existir,
+	// que são uniformes
+	Distribution string   `json:"distribution,omitempty"`
+	Player       int      `json:"player"`
+	Attacks      [][2]int `json:"attacks"`
+}
+
+// Dimensões da matriz para a qual a sequência foi gerada, zeradas quando o
+// arquivo não as registra
+func (s SequenceFile) Dimensions() (width, height int) {
+	if s.Width > 0 || s.Height > 0 {
+		return s.Width, s.Height
+	}
+	return s.Size, s.Size
+}
+
+func generateAttackSequence(rng *rand.Rand, shape Shape, numAttacks int) [][2]int {
 	sequence := make([][2]int, numAttacks)
 
+	// Com buracos, sorteia entre os blocos que existem
+	if shape.Holes != nil {
+		cells := shape.Cells()
+		for i := range sequence {
+			sequence[i] = cells[rng.Intn(len(cells))]
+		}
+		return sequence
+	}
+
 	// Preenche a sequência com coordenadas aleatórias
 	for i := 0; i < numAttacks; i++ {
-		x := rand.Intn(size)
-		y := rand.Intn(size)
+		x := rng.Intn(shape.Height)
+		y := rng.Intn(shape.Width)
 		sequence[i] = [2]int{x, y}
 	}
 
 	return sequence
 }
 
-func saveSequenceToFile(sequence [][2]int, filename string) error {
+func saveSequenceToFile(sequence SequenceFile, filename string) error {
 	data, err := json.Marshal(sequence)
 	if err != nil {
 		return err
@@ -32,22 +72,35 @@ func saveSequenceToFile(sequence [][2]int, filename string) error {
 	return os.WriteFile(filename, data, 0644)
 }
 
-// Função para ler a sequência de ataques de um arquivo
-func LoadSequenceFromFile(filename string) ([][2]int, error) {
+// Função para ler um arquivo de sequência, aceitando também o formato antigo
+func LoadSequenceFile(filename string) (SequenceFile, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
-		return nil, err
+		return SequenceFile{}, err
 	}
 
-	var sequence [][2]int
-	err = json.Unmarshal(data, &sequence)
+	var sequence SequenceFile
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		err = json.Unmarshal(data, &sequence.Attacks)
+	} else {
+		err = json.Unmarshal(data, &sequence)
+	}
 	if err != nil {
-		return nil, err
+		return SequenceFile{}, err
 	}
 
 	return sequence, nil
 }
 
+// Função para ler a sequência de ataques de um arquivo
+func LoadSequenceFromFile(filename string) ([][2]int, error) {
+	sequence, err := LoadSequenceFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return sequence.Attacks, nil
+}
+
 func FileExists(filename string) bool {
 	_, err := os.Stat(filename)
 	if err == nil {
@@ -59,16 +112,60 @@ func FileExists(filename string) bool {
 	return false
 }
 
-func Generate(size, numAttacks int) (bool, error) {
-	for i := 1; i <= 2; i++ {
-		sequence := generateAttackSequence(size, numAttacks)
-		filename := fmt.Sprintf("sequence_%d.json", i)
+// Retorna uma semente nova para quando o usuário não informa uma
+func NewSeed() int64 {
+	return time.Now().UnixNano()
+}
+
+// Nome do arquivo com a sequência de ataques do player
+func SequenceFilename(player int) string {
+	return fmt.Sprintf("sequence_%d.json", player)
+}
+
+// Indica se existe um arquivo de sequência para cada um dos players
+func SequencesExist(players int) bool {
+	for i := 1; i <= players; i++ {
+		if !FileExists(SequenceFilename(i)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Gera em memória uma sequência para cada player. A mesma semente, formato,
+// distribuição, número de ataques e de players sempre produzem as mesmas
+// sequências
+func NewSequences(shape Shape, numAttacks, players int, seed int64, distribution Distribution) []SequenceFile {
+	rng := rand.New(rand.NewSource(seed))
+	attacks := distribution.generate(rng, shape, players, numAttacks)
+	sequences := make([]SequenceFile, players)
+	for i := range sequences {
+		sequences[i] = SequenceFile{
+			Version:      SequenceVersion,
+			Seed:         seed,
+			Width:        shape.Width,
+			Height:       shape.Height,
+			Distribution: distribution.String(),
+			Player:       i + 1,
+			Attacks:      attacks[i],
+		}
+		if shape.Width == shape.Height {
+			sequences[i].Size = shape.Width
+		}
+	}
+	return sequences
+}
+
+// Gera uma sequência para cada player e salva nos arquivos sequence_N.json
+func Generate(shape Shape, numAttacks, players int, seed int64, distribution Distribution) (bool, error) {
+	for _, sequence := range NewSequences(shape, numAttacks, players, seed, distribution) {
+		filename := SequenceFilename(sequence.Player)
 		err := saveSequenceToFile(sequence, filename)
 		if err != nil {
 			logger.Error("Erro ao salvar a sequência", err)
 			return false, err
 		}
-		logger.Info("Sequência carregada em:", zap.String("filename", filename))
+		logger.Info("Sequência carregada em:", zap.String("filename", filename), zap.Int64("seed", seed), zap.Stringer("distribution", distribution))
 	}
 	return true, nil
 }
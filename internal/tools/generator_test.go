@@ -0,0 +1,139 @@
+package tools
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Muda o diretório de trabalho durante o teste, já que os arquivos de
+// sequência são sempre lidos e escritos no diretório atual
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestSequenceRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		sequence SequenceFile
+	}{
+		{
+			name: "versão atual",
+			sequence: SequenceFile{
+				Version: SequenceVersion,
+				Seed:    42,
+				Size:    4,
+				Player:  2,
+				Attacks: generateAttackSequence(rand.New(rand.NewSource(42)), NewShape(4, 4), 50),
+			},
+		},
+		{
+			name:     "sem ataques",
+			sequence: SequenceFile{Version: SequenceVersion, Seed: -7, Size: 1, Player: 1, Attacks: [][2]int{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "sequence.json")
+			if err := saveSequenceToFile(tt.sequence, filename); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := LoadSequenceFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.sequence) {
+				t.Errorf("LoadSequenceFile() = %+v, esperado %+v", got, tt.sequence)
+			}
+		})
+	}
+}
+
+func TestLoadLegacySequence(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "sequence.json")
+	if err := os.WriteFile(filename, []byte("[[0,1],[2,3]]"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadSequenceFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := SequenceFile{Attacks: [][2]int{{0, 1}, {2, 3}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadSequenceFile() = %+v, esperado %+v", got, want)
+	}
+}
+
+func TestLoadInvalidSequence(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, filename := range []string{invalid, filepath.Join(dir, "missing.json")} {
+		if _, err := LoadSequenceFile(filename); err == nil {
+			t.Errorf("LoadSequenceFile(%q) não retornou erro", filename)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	const (
+		size       = 5
+		numAttacks = 30
+		players    = 3
+		seed       = 1234
+	)
+
+	read := func(t *testing.T) [][]byte {
+		t.Helper()
+		chdir(t, t.TempDir())
+		if _, err := Generate(NewShape(size, size), numAttacks, players, seed, Uniform); err != nil {
+			t.Fatal(err)
+		}
+		if !SequencesExist(players) {
+			t.Fatal("Generate() não criou todos os arquivos")
+		}
+
+		files := make([][]byte, players)
+		for i := range files {
+			filename := SequenceFilename(i + 1)
+			sequence, err := LoadValidSequenceFile(filename, NewShape(size, size))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sequence.Player != i+1 || sequence.Seed != seed || len(sequence.Attacks) != numAttacks {
+				t.Errorf("%s: cabeçalho inesperado %+v", filename, sequence)
+			}
+			if files[i], err = os.ReadFile(filename); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return files
+	}
+
+	// A mesma semente gera arquivos idênticos
+	first := read(t)
+	second := read(t)
+	if !reflect.DeepEqual(first, second) {
+		t.Error("a mesma semente gerou arquivos diferentes")
+	}
+}
@@ -0,0 +1,37 @@
+package tools
+
+// Cria um canal sem limite de capacidade. Os envios em in nunca bloqueiam por
+// falta de espaço: as mensagens ficam numa fila até serem consumidas em out,
+// na mesma ordem em que foram enviadas. Fechar in fecha out depois que a
+// fila esvaziar
+func NewMailbox[T any]() (chan<- T, <-chan T) {
+	in := make(chan T)
+	out := make(chan T)
+
+	go func(recv chan T) {
+		defer close(out)
+		var queue []T
+		for recv != nil || len(queue) > 0 {
+			// Só tenta entregar quando existe mensagem na fila
+			var send chan T
+			var next T
+			if len(queue) > 0 {
+				send = out
+				next = queue[0]
+			}
+
+			select {
+			case msg, ok := <-recv:
+				if !ok {
+					recv = nil
+					continue
+				}
+				queue = append(queue, msg)
+			case send <- next:
+				queue = queue[1:]
+			}
+		}
+	}(in)
+
+	return in, out
+}
@@ -0,0 +1,24 @@
+package tools
+
+import "testing"
+
+func TestMailbox(t *testing.T) {
+	in, out := NewMailbox[int]()
+
+	// Os envios não bloqueiam mesmo sem ninguém lendo
+	for i := 0; i < 1000; i++ {
+		in <- i
+	}
+	close(in)
+
+	next := 0
+	for got := range out {
+		if got != next {
+			t.Fatalf("recebido %d, esperado %d", got, next)
+		}
+		next++
+	}
+	if next != 1000 {
+		t.Errorf("recebidas %d mensagens, esperado 1000", next)
+	}
+}
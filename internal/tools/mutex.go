@@ -2,6 +2,20 @@ package tools
 
 import "sync"
 
+// Operações de um sync.Mutex usadas pelos blocos, para que ele possa ser embrulhado
+type Locker interface {
+	Lock()
+	Unlock()
+	TryLock() bool
+}
+
+// Operações do PriorityMutex usadas pelos blocos
+type PriorityLocker interface {
+	Lock(highPriority bool)
+	Unlock(highPriority bool)
+	TryLock(highPriority bool) bool
+}
+
 type PriorityMutex struct {
 	normalChan       chan struct{}
 	highPriorityChan chan struct{}
@@ -32,3 +46,21 @@ func (pm *PriorityMutex) Unlock(highPriority bool) {
 		<-pm.normalChan
 	}
 }
+
+// Tenta fazer o Lock sem esperar. Retorna false se o lock está ocupado
+func (pm *PriorityMutex) TryLock(highPriority bool) bool {
+	queue := pm.normalChan
+	if highPriority {
+		queue = pm.highPriorityChan
+	}
+	select {
+	case queue <- struct{}{}:
+	default:
+		return false
+	}
+	if !pm.mutex.TryLock() {
+		<-queue
+		return false
+	}
+	return true
+}
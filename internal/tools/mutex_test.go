@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPriorityMutexExclusion(t *testing.T) {
+	pm := NewPriorityMutex()
+
+	var wg sync.WaitGroup
+	inside, counter := 0, 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		// Metade das goroutines usa prioridade alta e metade normal
+		go func(highPriority bool) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				pm.Lock(highPriority)
+				inside++
+				if inside != 1 {
+					t.Errorf("%d goroutines dentro da seção crítica", inside)
+				}
+				counter++
+				inside--
+				pm.Unlock(highPriority)
+			}
+		}(i%2 == 0)
+	}
+	wg.Wait()
+
+	if counter != 5000 {
+		t.Errorf("contador = %d, esperado 5000", counter)
+	}
+}
+
+func TestPriorityMutexBlocks(t *testing.T) {
+	tests := []struct {
+		name   string
+		holder bool
+		waiter bool
+	}{
+		{name: "normal espera normal", holder: false, waiter: false},
+		{name: "alta espera normal", holder: false, waiter: true},
+		{name: "normal espera alta", holder: true, waiter: false},
+		{name: "alta espera alta", holder: true, waiter: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pm := NewPriorityMutex()
+			pm.Lock(tt.holder)
+
+			acquired := make(chan struct{})
+			go func() {
+				pm.Lock(tt.waiter)
+				close(acquired)
+			}()
+
+			select {
+			case <-acquired:
+				t.Fatal("Lock() não bloqueou com o mutex ocupado")
+			case <-time.After(50 * time.Millisecond):
+			}
+
+			pm.Unlock(tt.holder)
+			select {
+			case <-acquired:
+			case <-time.After(time.Second):
+				t.Fatal("Lock() não retornou depois do Unlock()")
+			}
+			pm.Unlock(tt.waiter)
+		})
+	}
+}
+
+func TestPriorityMutexTryLock(t *testing.T) {
+	tests := []struct {
+		name string
+		// Prioridade de quem segura o lock, nil se ele está livre
+		holder       *bool
+		highPriority bool
+		want         bool
+	}{
+		{name: "livre", highPriority: false, want: true},
+		{name: "ocupado com a mesma prioridade", holder: new(bool), highPriority: false, want: false},
+		{name: "ocupado com outra prioridade", holder: new(bool), highPriority: true, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pm := NewPriorityMutex()
+			if tt.holder != nil {
+				pm.Lock(*tt.holder)
+				defer pm.Unlock(*tt.holder)
+			}
+			got := pm.TryLock(tt.highPriority)
+			if got != tt.want {
+				t.Fatalf("TryLock(%v) = %v, esperado %v", tt.highPriority, got, tt.want)
+			}
+			if got {
+				pm.Unlock(tt.highPriority)
+			}
+		})
+	}
+}
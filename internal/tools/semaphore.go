@@ -2,6 +2,13 @@ package tools
 
 type Semaphore chan int
 
+// Operações do semáforo usadas pelos blocos, para que ele possa ser embrulhado
+type Acquirer interface {
+	Acquire()
+	Release()
+	TryAcquire() bool
+}
+
 func NewSemaphore() *Semaphore {
 	sem := make(Semaphore, 1)
 	return &sem
@@ -14,3 +21,13 @@ func (s Semaphore) Acquire() {
 func (s Semaphore) Release() {
 	<-s
 }
+
+// Tenta adquirir o semáforo sem esperar. Retorna false se ele está ocupado
+func (s Semaphore) TryAcquire() bool {
+	select {
+	case s <- 1:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSemaphoreExclusion(t *testing.T) {
+	sem := NewSemaphore()
+
+	var wg sync.WaitGroup
+	inside, counter := 0, 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				sem.Acquire()
+				inside++
+				if inside != 1 {
+					t.Errorf("%d goroutines dentro da seção crítica", inside)
+				}
+				counter++
+				inside--
+				sem.Release()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if counter != 5000 {
+		t.Errorf("contador = %d, esperado 5000", counter)
+	}
+}
+
+func TestSemaphoreBlocks(t *testing.T) {
+	sem := NewSemaphore()
+	sem.Acquire()
+
+	acquired := make(chan struct{})
+	go func() {
+		sem.Acquire()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("Acquire() não bloqueou com o semáforo ocupado")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	sem.Release()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("Acquire() não retornou depois do Release()")
+	}
+	sem.Release()
+}
+
+func TestSemaphoreTryAcquire(t *testing.T) {
+	sem := NewSemaphore()
+	if !sem.TryAcquire() {
+		t.Fatal("TryAcquire() falhou com o semáforo livre")
+	}
+	if sem.TryAcquire() {
+		t.Fatal("TryAcquire() conseguiu o semáforo ocupado")
+	}
+	sem.Release()
+	if !sem.TryAcquire() {
+		t.Fatal("TryAcquire() falhou depois do Release()")
+	}
+	sem.Release()
+}
@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Caracteres de um arquivo de máscara: um bloco ou um buraco no tabuleiro
+const (
+	MaskBlock = '#'
+	MaskHole  = '.'
+)
+
+// Formato do tabuleiro: as dimensões da matriz e, opcionalmente, os buracos,
+// posições que não têm bloco e nunca são atacadas. As coordenadas seguem as
+// sequências de ataque: a primeira é a linha e a segunda a coluna
+type Shape struct {
+	Width  int
+	Height int
+	// Indica os buracos linha por linha, nil quando o tabuleiro é um retângulo cheio
+	Holes []bool
+}
+
+// Tabuleiro retangular sem buracos
+func NewShape(width, height int) Shape {
+	return Shape{Width: width, Height: height}
+}
+
+// Indica se a coordenada está dentro do tabuleiro e não é um buraco
+func (s Shape) Contains(row, col int) bool {
+	if row < 0 || row >= s.Height || col < 0 || col >= s.Width {
+		return false
+	}
+	return s.Holes == nil || !s.Holes[row*s.Width+col]
+}
+
+// Coordenadas de todos os blocos, linha por linha
+func (s Shape) Cells() [][2]int {
+	cells := make([][2]int, 0, s.Width*s.Height)
+	for row := range s.Height {
+		for col := range s.Width {
+			if s.Contains(row, col) {
+				cells = append(cells, [2]int{row, col})
+			}
+		}
+	}
+	return cells
+}
+
+// Máscara do tabuleiro, uma string por linha, ou nil se não há buracos
+func (s Shape) Mask() []string {
+	if s.Holes == nil {
+		return nil
+	}
+	rows := make([]string, s.Height)
+	for row := range rows {
+		var line strings.Builder
+		for col := range s.Width {
+			if s.Contains(row, col) {
+				line.WriteByte(MaskBlock)
+			} else {
+				line.WriteByte(MaskHole)
+			}
+		}
+		rows[row] = line.String()
+	}
+	return rows
+}
+
+func (s Shape) String() string {
+	if s.Holes == nil {
+		return fmt.Sprintf("%dx%d", s.Width, s.Height)
+	}
+	return fmt.Sprintf("%dx%d com %d buracos", s.Width, s.Height, s.Width*s.Height-len(s.Cells()))
+}
+
+// Monta o formato a partir das linhas de uma máscara, onde # é um bloco e .
+// é um buraco. Todas as linhas precisam ter a mesma largura
+func ShapeFromMask(rows []string) (Shape, error) {
+	if len(rows) == 0 {
+		return Shape{}, fmt.Errorf("a máscara está vazia")
+	}
+	shape := Shape{Width: len(rows[0]), Height: len(rows)}
+	shape.Holes = make([]bool, shape.Width*shape.Height)
+	for row, line := range rows {
+		if len(line) != shape.Width {
+			return Shape{}, fmt.Errorf("linha %d da máscara tem %d colunas, esperado %d", row+1, len(line), shape.Width)
+		}
+		for col, c := range line {
+			switch c {
+			case MaskBlock:
+			case MaskHole:
+				shape.Holes[row*shape.Width+col] = true
+			default:
+				return Shape{}, fmt.Errorf("linha %d da máscara: caractere %q inválido, use %c para blocos e %c para buracos", row+1, c, MaskBlock, MaskHole)
+			}
+		}
+	}
+	if len(shape.Cells()) == 0 {
+		return Shape{}, fmt.Errorf("a máscara não tem nenhum bloco")
+	}
+	return shape, nil
+}
+
+// Lê uma máscara com uma linha do tabuleiro por linha. Linhas vazias são
+// ignoradas
+func ParseMask(r io.Reader) (Shape, error) {
+	var rows []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			rows = append(rows, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Shape{}, err
+	}
+	return ShapeFromMask(rows)
+}
+
+// Lê um arquivo de máscara
+func LoadMask(filename string) (Shape, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return Shape{}, err
+	}
+	defer file.Close()
+	shape, err := ParseMask(file)
+	if err != nil {
+		return Shape{}, fmt.Errorf("%s: %w", filename, err)
+	}
+	return shape, nil
+}
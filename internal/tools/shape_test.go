@@ -0,0 +1,86 @@
+package tools
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMask(t *testing.T) {
+	shape, err := ParseMask(strings.NewReader("\n.##.\n####\n\n#..#\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shape.Width != 4 || shape.Height != 3 {
+		t.Fatalf("formato %dx%d, esperado 4x3", shape.Width, shape.Height)
+	}
+	tests := []struct {
+		row, col int
+		want     bool
+	}{
+		{row: 0, col: 0, want: false},
+		{row: 0, col: 1, want: true},
+		{row: 1, col: 3, want: true},
+		{row: 2, col: 2, want: false},
+		{row: 3, col: 0, want: false},
+		{row: 0, col: 4, want: false},
+	}
+	for _, tt := range tests {
+		if got := shape.Contains(tt.row, tt.col); got != tt.want {
+			t.Errorf("Contains(%d, %d) = %v, esperado %v", tt.row, tt.col, got, tt.want)
+		}
+	}
+	if got := len(shape.Cells()); got != 8 {
+		t.Errorf("%d blocos, esperado 8", got)
+	}
+	if got := shape.Mask(); !reflect.DeepEqual(got, []string{".##.", "####", "#..#"}) {
+		t.Errorf("Mask() = %v", got)
+	}
+}
+
+func TestParseMaskErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		mask string
+		want string
+	}{
+		{name: "vazia", mask: "\n", want: "vazia"},
+		{name: "linhas desiguais", mask: "##\n#\n", want: "linha 2"},
+		{name: "caractere inválido", mask: "#x\n", want: "'x'"},
+		{name: "só buracos", mask: "..\n..\n", want: "nenhum bloco"},
+	}
+	for _, tt := range tests {
+		_, err := ParseMask(strings.NewReader(tt.mask))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: erro %v, esperado contendo %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+// As sequências geradas respeitam as dimensões e nunca atacam um buraco
+func TestGenerateShape(t *testing.T) {
+	holes, err := ShapeFromMask([]string{"#.#", "...", "#.#"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, shape := range []Shape{NewShape(5, 2), NewShape(1, 7), holes} {
+		attacks := generateAttackSequence(rand.New(rand.NewSource(3)), shape, 200)
+		sequence := SequenceFile{Version: SequenceVersion, Width: shape.Width, Height: shape.Height, Attacks: attacks}
+		if errs := ValidateSequence(sequence, "sequence.json", shape); len(errs) > 0 {
+			t.Errorf("%v: %v", shape, errs)
+		}
+	}
+}
+
+// Sem buracos, um tabuleiro quadrado gera as mesmas sequências da versão 1
+func TestGenerateSquareCompatible(t *testing.T) {
+	rng := rand.New(rand.NewSource(9))
+	want := make([][2]int, 20)
+	for i := range want {
+		want[i] = [2]int{rng.Intn(6), rng.Intn(6)}
+	}
+	if got := generateAttackSequence(rand.New(rand.NewSource(9)), NewShape(6, 6), 20); !reflect.DeepEqual(got, want) {
+		t.Errorf("sequência %v, esperado %v", got, want)
+	}
+}
@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Problema encontrado num arquivo de sequência. Index é -1 quando o problema
+// é do arquivo como um todo e não de um ataque específico
+type SequenceError struct {
+	Filename string
+	Index    int
+	Coord    [2]int
+	Reason   string
+}
+
+func (e *SequenceError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("%s: %s", e.Filename, e.Reason)
+	}
+	return fmt.Sprintf("%s: ataque %d na coordenada [%d %d] %s", e.Filename, e.Index, e.Coord[0], e.Coord[1], e.Reason)
+}
+
+// Verifica se a sequência pode ser usada no tabuleiro e retorna todos os
+// problemas encontrados, não apenas o primeiro
+func ValidateSequence(sequence SequenceFile, filename string, shape Shape) []error {
+	var errs []error
+
+	// Arquivos no formato antigo não registram o tamanho da matriz
+	width, height := sequence.Dimensions()
+	if sequence.Version > 0 && (width != shape.Width || height != shape.Height) {
+		errs = append(errs, &SequenceError{
+			Filename: filename,
+			Index:    -1,
+			Reason:   fmt.Sprintf("a sequência foi gerada para uma matriz %dx%d, mas o jogo usa %dx%d", width, height, shape.Width, shape.Height),
+		})
+	}
+
+	for i, coord := range sequence.Attacks {
+		x, y := coord[0], coord[1]
+		if x < 0 || x >= shape.Height || y < 0 || y >= shape.Width {
+			errs = append(errs, &SequenceError{
+				Filename: filename,
+				Index:    i,
+				Coord:    coord,
+				Reason:   fmt.Sprintf("está fora da matriz %dx%d", shape.Width, shape.Height),
+			})
+		} else if !shape.Contains(x, y) {
+			errs = append(errs, &SequenceError{
+				Filename: filename,
+				Index:    i,
+				Coord:    coord,
+				Reason:   "cai num buraco do tabuleiro",
+			})
+		}
+	}
+
+	return errs
+}
+
+// Lê e valida um arquivo de sequência, retornando todos os problemas juntos
+func LoadValidSequenceFile(filename string, shape Shape) (SequenceFile, error) {
+	sequence, err := LoadSequenceFile(filename)
+	if err != nil {
+		return SequenceFile{}, fmt.Errorf("%s: %w", filename, err)
+	}
+	if errs := ValidateSequence(sequence, filename, shape); len(errs) > 0 {
+		return SequenceFile{}, errors.Join(errs...)
+	}
+	return sequence, nil
+}
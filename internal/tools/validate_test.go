@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateSequence(t *testing.T) {
+	tests := []struct {
+		name     string
+		sequence SequenceFile
+		shape    Shape
+		// Índices dos problemas esperados, -1 para problemas do arquivo
+		want []int
+	}{
+		{
+			name:     "válida",
+			sequence: SequenceFile{Version: SequenceVersion, Size: 3, Attacks: [][2]int{{0, 0}, {2, 2}, {1, 0}}},
+			shape:    NewShape(3, 3),
+		},
+		{
+			name:     "tamanho diferente",
+			sequence: SequenceFile{Version: SequenceVersion, Size: 4, Attacks: [][2]int{{0, 0}}},
+			shape:    NewShape(3, 3),
+			want:     []int{-1},
+		},
+		{
+			name:     "formato antigo não tem tamanho",
+			sequence: SequenceFile{Attacks: [][2]int{{0, 0}}},
+			shape:    NewShape(3, 3),
+		},
+		{
+			name:     "fora da matriz",
+			sequence: SequenceFile{Version: SequenceVersion, Size: 3, Attacks: [][2]int{{0, 0}, {3, 0}, {1, 1}, {0, -1}}},
+			shape:    NewShape(3, 3),
+			want:     []int{1, 3},
+		},
+		{
+			name:     "retângulo",
+			sequence: SequenceFile{Version: SequenceVersion, Width: 4, Height: 2, Attacks: [][2]int{{1, 3}, {3, 1}}},
+			shape:    NewShape(4, 2),
+			want:     []int{1},
+		},
+		{
+			name:     "versão 1 num retângulo",
+			sequence: SequenceFile{Version: 1, Size: 2, Attacks: [][2]int{{0, 0}}},
+			shape:    NewShape(4, 2),
+			want:     []int{-1},
+		},
+		{
+			name:     "buraco",
+			sequence: SequenceFile{Version: SequenceVersion, Width: 2, Height: 2, Attacks: [][2]int{{0, 0}, {0, 1}, {1, 1}}},
+			shape:    Shape{Width: 2, Height: 2, Holes: []bool{false, true, false, false}},
+			want:     []int{1},
+		},
+		{
+			name:     "todos os problemas",
+			sequence: SequenceFile{Version: SequenceVersion, Size: 2, Attacks: [][2]int{{5, 5}}},
+			shape:    NewShape(3, 3),
+			want:     []int{-1, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateSequence(tt.sequence, "sequence.json", tt.shape)
+			if len(errs) != len(tt.want) {
+				t.Fatalf("ValidateSequence() = %v, esperados %d problemas", errs, len(tt.want))
+			}
+			for i, err := range errs {
+				var seqErr *SequenceError
+				if !errors.As(err, &seqErr) {
+					t.Fatalf("erro %v não é um *SequenceError", err)
+				}
+				if seqErr.Index != tt.want[i] {
+					t.Errorf("problema %d no índice %d, esperado %d", i, seqErr.Index, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadValidSequenceFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "sequence.json")
+	sequence := SequenceFile{Version: SequenceVersion, Size: 2, Attacks: [][2]int{{2, 0}, {0, 2}}}
+	if err := saveSequenceToFile(sequence, filename); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadValidSequenceFile(filename, NewShape(2, 2)); err == nil {
+		t.Fatal("LoadValidSequenceFile() aceitou uma sequência inválida")
+	} else if joined, ok := err.(interface{ Unwrap() []error }); !ok || len(joined.Unwrap()) != 2 {
+		t.Errorf("LoadValidSequenceFile() = %v, esperados os 2 problemas", err)
+	}
+
+	if _, err := LoadValidSequenceFile(filename, NewShape(3, 3)); err == nil {
+		t.Error("LoadValidSequenceFile() ignorou o tamanho da matriz")
+	}
+}
@@ -0,0 +1,76 @@
+package trace
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/brnocorreia/concurrency/internal/entity"
+)
+
+// Linha do arquivo de eventos. Seq é a posição do evento no arquivo e T o
+// tempo desde a criação do Writer, lido do relógio monotônico enquanto o
+// Writer está travado, então os dois crescem juntos ao longo do arquivo
+type Record struct {
+	Seq  uint64        `json:"seq"`
+	T    time.Duration `json:"t_ns"`
+	Mode string        `json:"mode"`
+	entity.Event
+}
+
+// Writer grava os eventos do jogo em NDJSON, um objeto JSON por linha
+type Writer struct {
+	mutex   sync.Mutex
+	out     *bufio.Writer
+	encoder *json.Encoder
+	start   time.Time
+	seq     uint64
+	mode    string
+	// Primeiro erro de escrita, retornado pelo Flush
+	err error
+}
+
+func NewWriter(out io.Writer) *Writer {
+	buffered := bufio.NewWriter(out)
+	return &Writer{
+		out:     buffered,
+		encoder: json.NewEncoder(buffered),
+		start:   time.Now(),
+	}
+}
+
+// Define a estratégia registrada nos próximos eventos
+func (w *Writer) SetMode(mode string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.mode = mode
+}
+
+func (w *Writer) Emit(event entity.Event) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.err != nil {
+		return
+	}
+
+	w.seq++
+	w.err = w.encoder.Encode(Record{
+		Seq:   w.seq,
+		T:     time.Since(w.start),
+		Mode:  w.mode,
+		Event: event,
+	})
+}
+
+// Grava os eventos que ainda estão no buffer
+func (w *Writer) Flush() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	w.err = w.out.Flush()
+	return w.err
+}
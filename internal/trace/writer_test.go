@@ -0,0 +1,109 @@
+package trace
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/brnocorreia/concurrency/internal/entity"
+	"github.com/brnocorreia/concurrency/internal/runner"
+	"github.com/brnocorreia/concurrency/internal/tools"
+)
+
+func readRecords(t *testing.T, data []byte) []Record {
+	t.Helper()
+	var records []Record
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("linha inválida %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// Joga uma partida de cada estratégia gravando os eventos e confere o arquivo
+func TestWriterGame(t *testing.T) {
+	var out bytes.Buffer
+	writer := NewWriter(&out)
+
+	game := runner.NewRunner(30, 3, 40, 3, tools.NewVirtualClock(time.Unix(0, 0)))
+	game.SetSequences(tools.NewSequences(tools.NewShape(3, 3), 30, 3, 8, tools.Uniform))
+	game.SetOutput(io.Discard)
+	game.SetEvents(writer)
+
+	results := make(map[string]runner.RunResult)
+	for _, strategy := range entity.Strategies() {
+		writer.SetMode(strategy.Name)
+		results[strategy.Name] = game.Run(context.Background(), strategy)
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	records := readRecords(t, out.Bytes())
+	kinds := make(map[string]map[entity.EventKind]int)
+	kills := make(map[string]map[int]int)
+	for i, record := range records {
+		if record.Seq != uint64(i+1) {
+			t.Fatalf("linha %d com seq %d", i+1, record.Seq)
+		}
+		if i > 0 && record.T < records[i-1].T {
+			t.Fatalf("linha %d com tempo %v antes da anterior %v", i+1, record.T, records[i-1].T)
+		}
+		if record.Goroutine == "" || record.Player < 1 || record.Player > 3 || record.Block < 1 || record.Block > 9 {
+			t.Fatalf("linha %d incompleta: %+v", i+1, record)
+		}
+
+		if kinds[record.Mode] == nil {
+			kinds[record.Mode] = make(map[entity.EventKind]int)
+			kills[record.Mode] = make(map[int]int)
+		}
+		kinds[record.Mode][record.Kind]++
+		if record.Kind == entity.EventKill {
+			kills[record.Mode][record.Player]++
+		}
+	}
+
+	for mode, result := range results {
+		// Todo ataque começa com um attempt
+		if got, want := kinds[mode][entity.EventAttempt], 3*30; got != want {
+			t.Errorf("%s: %d attempts, esperado %d", mode, got, want)
+		}
+		if kinds[mode][entity.EventLockAcquired] != kinds[mode][entity.EventLockReleased] {
+			t.Errorf("%s: %d locks adquiridos e %d liberados", mode, kinds[mode][entity.EventLockAcquired], kinds[mode][entity.EventLockReleased])
+		}
+		if mode == entity.MessageStrategy.Name {
+			if kinds[mode][entity.EventUpdatePropagated] != 2*kinds[mode][entity.EventHit] {
+				t.Errorf("%s: %d atualizações para %d acertos em 3 réplicas", mode, kinds[mode][entity.EventUpdatePropagated], kinds[mode][entity.EventHit])
+			}
+			continue
+		}
+		for _, player := range result.Players {
+			if player.Points != kills[mode][player.Id] {
+				t.Errorf("%s: player %d com %d pontos e %d kills", mode, player.Id, player.Points, kills[mode][player.Id])
+			}
+		}
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disco cheio")
+}
+
+func TestWriterError(t *testing.T) {
+	writer := NewWriter(failingWriter{})
+	writer.Emit(entity.Event{Kind: entity.EventHit, Player: 1, Block: 1})
+	if err := writer.Flush(); err == nil {
+		t.Error("Flush() não retornou o erro de escrita")
+	}
+}
@@ -0,0 +1,215 @@
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brnocorreia/concurrency/internal/entity"
+)
+
+// Intervalo entre dois quadros da tela
+const frameInterval = 50 * time.Millisecond
+
+// Sequências ANSI usadas para desenhar a tela
+const (
+	clearScreen = "\x1b[2J"
+	cursorHome  = "\x1b[H"
+	clearLine   = "\x1b[K"
+	clearBelow  = "\x1b[J"
+	hideCursor  = "\x1b[?25l"
+	showCursor  = "\x1b[?25h"
+	reset       = "\x1b[0m"
+
+	colorLocked = "\x1b[1;97;45m"
+	colorDead   = "\x1b[37;100m"
+	colorHigh   = "\x1b[30;42m"
+	colorMedium = "\x1b[30;43m"
+	colorLow    = "\x1b[97;41m"
+)
+
+// Tela que mostra o tabuleiro enquanto o jogo acontece. Ela é um Sink dos
+// eventos dos blocos: cada evento só atualiza o estado guardado, e uma
+// goroutine redesenha a tela em intervalos fixos. Na troca de mensagens os
+// eventos de todas as réplicas aparecem sobrepostos na mesma matriz
+type Screen struct {
+	out    io.Writer
+	label  string
+	width  int
+	height int
+	// Saúde de cada bloco no início do jogo, usada para escolher as cores
+	initial []int
+
+	mutex sync.Mutex
+	// Saúde e player que segura o lock de cada bloco, na ordem dos ids
+	health []int
+	holder []int
+	// Pontos e ataques certeiros de cada player, na ordem dos ids
+	points []int
+	hits   []int
+	start  time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewScreen(out io.Writer, label string, width, height, players int, rules entity.BlockRules) *Screen {
+	screen := &Screen{
+		out:     out,
+		label:   label,
+		width:   width,
+		height:  height,
+		initial: make([]int, width*height),
+		health:  make([]int, width*height),
+		holder:  make([]int, width*height),
+		points:  make([]int, players),
+		hits:    make([]int, players),
+	}
+	for i := range screen.health {
+		screen.initial[i] = rules.Type(i + 1).Health
+		screen.health[i] = screen.initial[i]
+	}
+	return screen
+}
+
+func (s *Screen) Emit(event entity.Event) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	block, player := event.Block-1, event.Player-1
+	if block < 0 || block >= len(s.health) || player < 0 || player >= len(s.points) {
+		return
+	}
+
+	switch event.Kind {
+	case entity.EventLockAcquired:
+		s.holder[block] = event.Player
+	case entity.EventLockReleased:
+		if s.holder[block] == event.Player {
+			s.holder[block] = 0
+		}
+		s.health[block] = event.Health
+	case entity.EventHit:
+		s.health[block] = event.Health
+		s.hits[player]++
+	case entity.EventKill:
+		s.health[block] = 0
+		s.points[player]++
+	}
+}
+
+// Limpa o terminal e começa a redesenhar a tela
+func (s *Screen) Start() {
+	s.start = time.Now()
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	fmt.Fprint(s.out, hideCursor+clearScreen)
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(frameInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.draw()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Para de redesenhar, desenha o último quadro e devolve o cursor
+func (s *Screen) Stop() {
+	close(s.stop)
+	<-s.done
+	s.draw()
+	fmt.Fprint(s.out, showCursor+"\n")
+}
+
+func (s *Screen) draw() {
+	var frame bytes.Buffer
+	frame.WriteString(cursorHome)
+	s.render(&frame)
+	frame.WriteString(clearBelow)
+	s.out.Write(frame.Bytes())
+}
+
+// Monta um quadro: a matriz à esquerda e o placar dos players à direita
+func (s *Screen) render(frame *bytes.Buffer) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	fmt.Fprintf(frame, " %s  %v%s\n%s\n", s.label, time.Since(s.start).Round(100*time.Millisecond), clearLine, clearLine)
+
+	sidebar := []string{"PLAYER  POINTS  HITS"}
+	for i := range s.points {
+		sidebar = append(sidebar, fmt.Sprintf("P%-5d  %6d  %4d", i+1, s.points[i], s.hits[i]))
+	}
+
+	rows := s.height
+	if len(sidebar) > rows {
+		rows = len(sidebar)
+	}
+	blank := strings.Repeat(" ", s.width*cellWidth)
+	for row := 0; row < rows; row++ {
+		if row < s.height {
+			for col := 0; col < s.width; col++ {
+				i := row*s.width + col
+				frame.WriteString(s.cell(i))
+			}
+		} else {
+			frame.WriteString(blank)
+		}
+		if row < len(sidebar) {
+			frame.WriteString("   " + sidebar[row])
+		}
+		frame.WriteString(clearLine + "\n")
+	}
+
+	fmt.Fprintf(frame, "%s\n %s Pn %s locked by player n   %s >66 %s   %s >33 %s   %s <=33 %s   %s dead %s%s\n",
+		clearLine,
+		colorLocked, reset,
+		colorHigh, reset,
+		colorMedium, reset,
+		colorLow, reset,
+		colorDead, reset,
+		clearLine)
+}
+
+// Largura de uma célula da matriz na tela
+const cellWidth = 9
+
+// Desenha um bloco com a cor da sua saúde em relação à inicial, ou
+// destacado com o player que segura o lock
+func (s *Screen) cell(i int) string {
+	health, holder, initial := s.health[i], s.holder[i], s.initial[i]
+	// Só os buracos do tabuleiro começam sem saúde
+	if initial == 0 {
+		return strings.Repeat(" ", cellWidth)
+	}
+	owner := ""
+	if holder > 0 {
+		owner = fmt.Sprintf("P%d", holder)
+	}
+	text := fmt.Sprintf(" %3d %-4s", health, owner)
+
+	var color string
+	switch {
+	case holder > 0:
+		color = colorLocked
+	case health <= 0:
+		color = colorDead
+	case health*3 > initial*2:
+		color = colorHigh
+	case health*3 > initial:
+		color = colorMedium
+	default:
+		color = colorLow
+	}
+	return color + text + reset
+}
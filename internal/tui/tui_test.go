@@ -0,0 +1,67 @@
+package tui
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/brnocorreia/concurrency/internal/entity"
+)
+
+func TestScreenEmit(t *testing.T) {
+	screen := NewScreen(&bytes.Buffer{}, "MUTEX", 2, 2, 2, entity.DefaultBlockRules)
+
+	events := []entity.Event{
+		{Kind: entity.EventLockAcquired, Player: 2, Block: 3, Health: 100},
+		{Kind: entity.EventHit, Player: 2, Block: 3, Health: 0},
+		{Kind: entity.EventKill, Player: 2, Block: 3, Health: 0},
+		{Kind: entity.EventLockAcquired, Player: 1, Block: 1, Health: 100},
+		{Kind: entity.EventHit, Player: 1, Block: 1, Health: 70},
+		// Eventos de blocos ou players fora do tabuleiro são ignorados
+		{Kind: entity.EventHit, Player: 3, Block: 1, Health: 10},
+		{Kind: entity.EventHit, Player: 1, Block: 5, Health: 10},
+	}
+	for _, event := range events {
+		screen.Emit(event)
+	}
+
+	if got, want := screen.health, []int{70, 100, 0, 100}; !slices.Equal(got, want) {
+		t.Errorf("saúde = %v, esperado %v", got, want)
+	}
+	if got, want := screen.holder, []int{1, 0, 2, 0}; !slices.Equal(got, want) {
+		t.Errorf("locks = %v, esperado %v", got, want)
+	}
+	if got, want := screen.points, []int{0, 1}; !slices.Equal(got, want) {
+		t.Errorf("pontos = %v, esperado %v", got, want)
+	}
+
+	screen.Emit(entity.Event{Kind: entity.EventLockReleased, Player: 2, Block: 3, Health: 0})
+	if screen.holder[2] != 0 {
+		t.Error("o lock não foi liberado")
+	}
+	// Só quem segura o lock pode liberá-lo
+	screen.Emit(entity.Event{Kind: entity.EventLockReleased, Player: 2, Block: 1, Health: 70})
+	if screen.holder[0] != 1 {
+		t.Error("o lock foi liberado por outro player")
+	}
+}
+
+func TestScreenRender(t *testing.T) {
+	var out bytes.Buffer
+	screen := NewScreen(&out, "MUTEX", 3, 1, 4, entity.DefaultBlockRules)
+	screen.Emit(entity.Event{Kind: entity.EventLockAcquired, Player: 4, Block: 2, Health: 100})
+	screen.Start()
+	screen.Stop()
+
+	frame := out.String()
+	for _, want := range []string{"MUTEX", "P4", colorLocked + " 100 P4", showCursor} {
+		if !strings.Contains(frame, want) {
+			t.Errorf("o quadro não contém %q", want)
+		}
+	}
+	// Uma linha de cabeçalho e uma por player no placar
+	if got := strings.Count(frame, "PLAYER"); got != 1 {
+		t.Errorf("%d cabeçalhos no placar, esperado 1", got)
+	}
+}
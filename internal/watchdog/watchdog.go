@@ -0,0 +1,224 @@
+package watchdog
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brnocorreia/concurrency/internal/entity"
+)
+
+// Lock de um bloco. Replica é 0 nas estratégias com uma única matriz
+type lock struct {
+	block   int
+	replica int
+}
+
+func (l lock) String() string {
+	if l.replica == 0 {
+		return fmt.Sprintf("bloco %d", l.block)
+	}
+	return fmt.Sprintf("bloco %d da réplica %d", l.block, l.replica)
+}
+
+// Watchdog acompanha os eventos do jogo e dispara quando nenhum evento
+// acontece durante a janela. Pelos eventos ele sabe qual goroutine espera
+// por qual lock e quem o segura, o que monta o grafo de espera do relatório
+type Watchdog struct {
+	window time.Duration
+	mutex  sync.Mutex
+	last   time.Time
+	// Lock esperado por cada goroutine e a goroutine que segura cada lock
+	waiting map[string]lock
+	holders map[lock]string
+	// Ataques começados e blocos destruídos por cada player, que o runner
+	// lê do relatório porque os players travados ainda estão rodando
+	started map[int]int
+	points  map[int]int
+}
+
+func New(window time.Duration) *Watchdog {
+	return &Watchdog{
+		window:  window,
+		last:    time.Now(),
+		waiting: make(map[string]lock),
+		holders: make(map[lock]string),
+		started: make(map[int]int),
+		points:  make(map[int]int),
+	}
+}
+
+func (w *Watchdog) Emit(event entity.Event) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.last = time.Now()
+
+	current := lock{block: event.Block, replica: event.Replica}
+	switch event.Kind {
+	case entity.EventAttempt:
+		// Só a goroutine do player emite o início do ataque
+		w.started[event.Player]++
+		w.waiting[event.Goroutine] = current
+		return
+	case entity.EventLockWait:
+		w.waiting[event.Goroutine] = current
+		return
+	case entity.EventLockAcquired:
+		w.holders[current] = event.Goroutine
+	case entity.EventLockReleased:
+		if w.holders[current] == event.Goroutine {
+			delete(w.holders, current)
+		}
+	case entity.EventKill:
+		w.points[event.Player]++
+	}
+
+	// Qualquer outro evento mostra que a goroutine deixou de esperar. Nos
+	// atores quem consegue o lock é a goroutine do bloco, em nome do player
+	delete(w.waiting, event.Goroutine)
+	player := entity.PlayerGoroutine(event.Player)
+	if waiting, ok := w.waiting[player]; ok && waiting == current {
+		delete(w.waiting, player)
+	}
+}
+
+// Aguarda até que done seja fechado, retornando nil, ou até que a janela
+// passe sem nenhum evento, retornando o diagnóstico
+func (w *Watchdog) Watch(done <-chan struct{}) *Report {
+	w.mutex.Lock()
+	w.last = time.Now()
+	w.mutex.Unlock()
+
+	ticker := time.NewTicker(max(w.window/10, time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return nil
+		case <-ticker.C:
+			if report := w.check(); report != nil {
+				return report
+			}
+		}
+	}
+}
+
+func (w *Watchdog) check() *Report {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	idle := time.Since(w.last)
+	if idle < w.window {
+		return nil
+	}
+
+	report := &Report{Window: w.window, Idle: idle, Stacks: stacks()}
+	for goroutine, waiting := range w.waiting {
+		report.Waits = append(report.Waits, Wait{
+			Goroutine: goroutine,
+			Block:     waiting.block,
+			Replica:   waiting.replica,
+			Holder:    w.holders[waiting],
+		})
+	}
+	slices.SortFunc(report.Waits, func(a, b Wait) int { return strings.Compare(a.Goroutine, b.Goroutine) })
+	report.Cycle = findCycle(report.Waits)
+	for player, started := range w.started {
+		report.Players = append(report.Players, Progress{Player: player, Started: started, Points: w.points[player]})
+	}
+	slices.SortFunc(report.Players, func(a, b Progress) int { return a.Player - b.Player })
+	return report
+}
+
+// Pilhas de todas as goroutines do processo
+func stacks() string {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// Aresta do grafo de espera: a goroutine espera pelo lock do bloco, que está
+// com Holder. Holder é vazio quando ninguém segura o lock conhecidamente
+type Wait struct {
+	Goroutine string `json:"goroutine"`
+	Block     int    `json:"block"`
+	Replica   int    `json:"replica,omitempty"`
+	Holder    string `json:"holder,omitempty"`
+}
+
+// Progresso de um player até o disparo, contado pelos eventos
+type Progress struct {
+	Player  int `json:"player"`
+	Started int `json:"started"`
+	Points  int `json:"points"`
+}
+
+// Diagnóstico do watchdog quando o jogo para de progredir
+type Report struct {
+	Window time.Duration `json:"window_ns"`
+	// Tempo desde o último evento
+	Idle  time.Duration `json:"idle_ns"`
+	Waits []Wait        `json:"waits"`
+	// Goroutines que esperam umas pelas outras, com a primeira repetida no
+	// fim. Vazio quando as esperas não formam um ciclo
+	Cycle []string `json:"cycle,omitempty"`
+	// Progresso dos players que começaram algum ataque, em ordem de id
+	Players []Progress `json:"players,omitempty"`
+	// Pilhas de todas as goroutines no momento do disparo
+	Stacks string `json:"-"`
+}
+
+// Segue as esperas a partir de cada goroutine até voltar a uma goroutine já
+// visitada no mesmo caminho. Cada goroutine espera no máximo um lock, então
+// cada caminho tem no máximo um ciclo
+func findCycle(waits []Wait) []string {
+	next := make(map[string]string)
+	for _, wait := range waits {
+		if wait.Holder != "" {
+			next[wait.Goroutine] = wait.Holder
+		}
+	}
+	for _, wait := range waits {
+		var path []string
+		for goroutine, ok := wait.Goroutine, true; ok; goroutine, ok = next[goroutine] {
+			if i := slices.Index(path, goroutine); i >= 0 {
+				return append(path[i:], goroutine)
+			}
+			path = append(path, goroutine)
+		}
+	}
+	return nil
+}
+
+// Escreve o grafo de espera e, se withStacks, as pilhas das goroutines
+func (r *Report) Fprint(out io.Writer, withStacks bool) {
+	fmt.Fprintf(out, "Nenhum evento em %v (janela de %v)\n", r.Idle.Round(time.Millisecond), r.Window)
+	if len(r.Waits) == 0 {
+		fmt.Fprintln(out, "Nenhuma goroutine esperando por um lock")
+	} else {
+		fmt.Fprintln(out, "Grafo de espera:")
+	}
+	for _, wait := range r.Waits {
+		waiting := lock{block: wait.Block, replica: wait.Replica}
+		if wait.Holder == "" {
+			fmt.Fprintf(out, "  %s espera o %s\n", wait.Goroutine, waiting)
+		} else {
+			fmt.Fprintf(out, "  %s espera o %s, com o lock de %s\n", wait.Goroutine, waiting, wait.Holder)
+		}
+	}
+	if len(r.Cycle) > 0 {
+		fmt.Fprintf(out, "Deadlock: %s\n", strings.Join(r.Cycle, " -> "))
+	}
+	if withStacks {
+		fmt.Fprintln(out, "Pilhas das goroutines:")
+		fmt.Fprintln(out, r.Stacks)
+	}
+}
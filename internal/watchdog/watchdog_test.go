@@ -0,0 +1,178 @@
+package watchdog
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brnocorreia/concurrency/internal/entity"
+)
+
+func TestWatchdogGraph(t *testing.T) {
+	tests := []struct {
+		name      string
+		events    []entity.Event
+		wantWaits []Wait
+		wantCycle []string
+	}{
+		{
+			name: "troca de mensagens travada",
+			events: []entity.Event{
+				{Kind: entity.EventAttempt, Player: 1, Block: 3, Replica: 1, Goroutine: "player-1"},
+				{Kind: entity.EventLockAcquired, Player: 1, Block: 3, Replica: 1, Goroutine: "player-1"},
+				{Kind: entity.EventAttempt, Player: 2, Block: 3, Replica: 2, Goroutine: "player-2"},
+				{Kind: entity.EventLockAcquired, Player: 2, Block: 3, Replica: 2, Goroutine: "player-2"},
+				{Kind: entity.EventLockWait, Player: 1, Block: 3, Replica: 2, Goroutine: "sync-locks-1"},
+				{Kind: entity.EventLockWait, Player: 2, Block: 3, Replica: 1, Goroutine: "sync-locks-2"},
+				{Kind: entity.EventHit, Player: 1, Block: 3, Replica: 1, Goroutine: "player-1"},
+				{Kind: entity.EventLockWait, Player: 1, Block: 3, Replica: 1, Goroutine: "update-matrix"},
+			},
+			wantWaits: []Wait{
+				{Goroutine: "sync-locks-1", Block: 3, Replica: 2, Holder: "player-2"},
+				{Goroutine: "sync-locks-2", Block: 3, Replica: 1, Holder: "player-1"},
+				{Goroutine: "update-matrix", Block: 3, Replica: 1, Holder: "player-1"},
+			},
+		},
+		{
+			name: "ciclo entre players",
+			events: []entity.Event{
+				{Kind: entity.EventLockAcquired, Player: 1, Block: 1, Goroutine: "player-1"},
+				{Kind: entity.EventLockAcquired, Player: 2, Block: 2, Goroutine: "player-2"},
+				{Kind: entity.EventLockAcquired, Player: 3, Block: 3, Goroutine: "player-3"},
+				{Kind: entity.EventAttempt, Player: 1, Block: 2, Goroutine: "player-1"},
+				{Kind: entity.EventAttempt, Player: 2, Block: 3, Goroutine: "player-2"},
+				{Kind: entity.EventAttempt, Player: 3, Block: 2, Goroutine: "player-3"},
+			},
+			wantWaits: []Wait{
+				{Goroutine: "player-1", Block: 2, Holder: "player-2"},
+				{Goroutine: "player-2", Block: 3, Holder: "player-3"},
+				{Goroutine: "player-3", Block: 2, Holder: "player-2"},
+			},
+			wantCycle: []string{"player-2", "player-3", "player-2"},
+		},
+		{
+			name: "ator atende o player",
+			events: []entity.Event{
+				{Kind: entity.EventAttempt, Player: 1, Block: 4, Goroutine: "player-1"},
+				{Kind: entity.EventAttempt, Player: 2, Block: 4, Goroutine: "player-2"},
+				{Kind: entity.EventLockAcquired, Player: 1, Block: 4, Goroutine: "actor-4"},
+			},
+			wantWaits: []Wait{
+				{Goroutine: "player-2", Block: 4, Holder: "actor-4"},
+			},
+		},
+		{
+			name: "lock liberado",
+			events: []entity.Event{
+				{Kind: entity.EventLockAcquired, Player: 1, Block: 1, Goroutine: "player-1"},
+				{Kind: entity.EventAttempt, Player: 2, Block: 1, Goroutine: "player-2"},
+				{Kind: entity.EventLockReleased, Player: 1, Block: 1, Goroutine: "player-1"},
+			},
+			wantWaits: []Wait{
+				{Goroutine: "player-2", Block: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dog := New(20 * time.Millisecond)
+			for _, event := range tt.events {
+				dog.Emit(event)
+			}
+
+			report := dog.Watch(make(chan struct{}))
+			if report == nil {
+				t.Fatal("Watch() não disparou sem eventos")
+			}
+			if report.Idle < report.Window {
+				t.Errorf("Idle = %v, menor que a janela %v", report.Idle, report.Window)
+			}
+			if !reflect.DeepEqual(report.Waits, tt.wantWaits) {
+				t.Errorf("Waits = %+v, esperado %+v", report.Waits, tt.wantWaits)
+			}
+			if !reflect.DeepEqual(report.Cycle, tt.wantCycle) {
+				t.Errorf("Cycle = %v, esperado %v", report.Cycle, tt.wantCycle)
+			}
+			if !strings.Contains(report.Stacks, "goroutine") {
+				t.Error("o relatório não tem as pilhas das goroutines")
+			}
+		})
+	}
+}
+
+func TestWatchdogProgress(t *testing.T) {
+	dog := New(100 * time.Millisecond)
+	done := make(chan struct{})
+
+	// Eventos mais frequentes que a janela mantêm o watchdog quieto até o fim
+	go func() {
+		defer close(done)
+		for range 20 {
+			dog.Emit(entity.Event{Kind: entity.EventHit, Player: 1, Block: 1, Goroutine: "player-1"})
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	if report := dog.Watch(done); report != nil {
+		t.Errorf("Watch() disparou com o jogo progredindo: %+v", report)
+	}
+}
+
+func TestWatchdogPlayers(t *testing.T) {
+	dog := New(20 * time.Millisecond)
+	for _, event := range []entity.Event{
+		{Kind: entity.EventAttempt, Player: 2, Block: 1, Goroutine: "player-2"},
+		{Kind: entity.EventLockAcquired, Player: 2, Block: 1, Goroutine: "player-2"},
+		{Kind: entity.EventHit, Player: 2, Block: 1, Goroutine: "player-2"},
+		{Kind: entity.EventKill, Player: 2, Block: 1, Goroutine: "player-2"},
+		{Kind: entity.EventLockReleased, Player: 2, Block: 1, Goroutine: "player-2"},
+		{Kind: entity.EventAttempt, Player: 1, Block: 1, Goroutine: "player-1"},
+		// O lock pego pelo SyncLocks não é um ataque do player
+		{Kind: entity.EventLockWait, Player: 1, Block: 1, Replica: 2, Goroutine: "sync-locks-1"},
+		{Kind: entity.EventAttempt, Player: 2, Block: 2, Goroutine: "player-2"},
+	} {
+		dog.Emit(event)
+	}
+
+	report := dog.Watch(make(chan struct{}))
+	want := []Progress{{Player: 1, Started: 1}, {Player: 2, Started: 2, Points: 1}}
+	if !reflect.DeepEqual(report.Players, want) {
+		t.Errorf("Players = %+v, esperado %+v", report.Players, want)
+	}
+}
+
+func TestReportFprint(t *testing.T) {
+	report := Report{
+		Window: time.Second,
+		Idle:   2 * time.Second,
+		Waits: []Wait{
+			{Goroutine: "player-1", Block: 2, Replica: 1, Holder: "sync-locks-2"},
+			{Goroutine: "sync-locks-2", Block: 7, Replica: 1},
+		},
+		Cycle:  []string{"a", "b", "a"},
+		Stacks: "goroutine 1 [running]:",
+	}
+
+	var out bytes.Buffer
+	report.Fprint(&out, false)
+	for _, want := range []string{
+		"player-1 espera o bloco 2 da réplica 1, com o lock de sync-locks-2",
+		"sync-locks-2 espera o bloco 7 da réplica 1\n",
+		"Deadlock: a -> b -> a",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Fprint() = %q, esperado conter %q", out.String(), want)
+		}
+	}
+	if strings.Contains(out.String(), report.Stacks) {
+		t.Error("Fprint() sem pilhas escreveu as pilhas")
+	}
+
+	out.Reset()
+	report.Fprint(&out, true)
+	if !strings.Contains(out.String(), report.Stacks) {
+		t.Error("Fprint() não escreveu as pilhas")
+	}
+}